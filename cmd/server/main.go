@@ -1,15 +1,19 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
 	mailerhandlers "monolith-domain/internal/mailer/application/handlers"
 	mailerservices "monolith-domain/internal/mailer/application/services"
+	mailerdomain "monolith-domain/internal/mailer/domain"
 	mailerinfra "monolith-domain/internal/mailer/infrastructure"
 	newsletterhandlers "monolith-domain/internal/newsletter/application/handlers"
 	newsletterservices "monolith-domain/internal/newsletter/application/services"
@@ -19,6 +23,7 @@ import (
 	resourceservices "monolith-domain/internal/resources/application/services"
 	resourceinfra "monolith-domain/internal/resources/infrastructure"
 	resourcedomain "monolith-domain/internal/resources/domain"
+	"monolith-domain/internal/sharedkernel"
 	"monolith-domain/pkg/config"
 	"monolith-domain/pkg/observability"
 	"monolith-domain/pkg/router"
@@ -75,6 +80,7 @@ func initPrometheusMetrics() {
 	registry.MustRegister(httpRequestsTotal)
 	registry.MustRegister(httpRequestDuration)
 	registry.MustRegister(httpRequestsInFlight)
+	observability.RegisterMailMetrics(registry)
 }
 
 func setupMiddlewares(app *fiber.App) {
@@ -148,6 +154,12 @@ func initializeDatabase(cfg *config.Config, logger *zap.Logger) (*gorm.DB, error
 	migrator := db.Migrator()
 	newsletterTableExists := migrator.HasTable(&domain.Newsletter{})
 	resourceTableExists := migrator.HasTable(&resourcedomain.Resource{})
+	outboxTableExists := migrator.HasTable(&mailerdomain.OutboxMessage{})
+	templateTableExists := migrator.HasTable(&mailerdomain.EmailTemplate{})
+	deliveryLogTableExists := migrator.HasTable(&mailerdomain.DeliveryLogEntry{})
+	suppressionTableExists := migrator.HasTable(&mailerdomain.Suppression{})
+	idempotencyTableExists := migrator.HasTable(&mailerdomain.IdempotencyRecord{})
+	trackingTableExists := migrator.HasTable(&mailerdomain.TrackingEvent{})
 
 	if !newsletterTableExists {
 		logger.Info("Starting newsletter table migration...")
@@ -167,67 +179,372 @@ func initializeDatabase(cfg *config.Config, logger *zap.Logger) (*gorm.DB, error
 		logger.Info("Resource table migration completed successfully")
 	}
 
-	if newsletterTableExists && resourceTableExists {
+	if !outboxTableExists {
+		logger.Info("Starting mail outbox table migration...")
+		if err := db.AutoMigrate(&mailerdomain.OutboxMessage{}); err != nil {
+			logger.Error("Mail outbox migration failed", zap.Error(err))
+			return nil, fmt.Errorf("failed to migrate mail outbox table: %w", err)
+		}
+		logger.Info("Mail outbox table migration completed successfully")
+	}
+
+	// scheduled_at was added to the outbox after the table was first released
+	if outboxTableExists && !migrator.HasColumn(&mailerdomain.OutboxMessage{}, "ScheduledAt") {
+		logger.Info("Adding scheduled_at column to mail outbox table...")
+		if err := migrator.AddColumn(&mailerdomain.OutboxMessage{}, "ScheduledAt"); err != nil {
+			logger.Error("Mail outbox migration failed", zap.Error(err))
+			return nil, fmt.Errorf("failed to add scheduled_at to mail outbox table: %w", err)
+		}
+	}
+
+	if !templateTableExists {
+		logger.Info("Starting email template table migration...")
+		if err := db.AutoMigrate(&mailerdomain.EmailTemplate{}); err != nil {
+			logger.Error("Email template migration failed", zap.Error(err))
+			return nil, fmt.Errorf("failed to migrate email template table: %w", err)
+		}
+		logger.Info("Email template table migration completed successfully")
+	}
+
+	if !deliveryLogTableExists {
+		logger.Info("Starting mail delivery log table migration...")
+		if err := db.AutoMigrate(&mailerdomain.DeliveryLogEntry{}); err != nil {
+			logger.Error("Mail delivery log migration failed", zap.Error(err))
+			return nil, fmt.Errorf("failed to migrate mail delivery log table: %w", err)
+		}
+		logger.Info("Mail delivery log table migration completed successfully")
+	}
+
+	// Campaign and engagement columns were added to the delivery log after its table was first released
+	if deliveryLogTableExists {
+		for _, column := range []string{"Campaign", "Opens", "Clicks", "OpenedAt", "ClickedAt"} {
+			if migrator.HasColumn(&mailerdomain.DeliveryLogEntry{}, column) {
+				continue
+			}
+			logger.Info("Adding column to mail delivery log table...", zap.String("column", column))
+			if err := migrator.AddColumn(&mailerdomain.DeliveryLogEntry{}, column); err != nil {
+				logger.Error("Mail delivery log migration failed", zap.Error(err))
+				return nil, fmt.Errorf("failed to add %s to mail delivery log table: %w", column, err)
+			}
+		}
+		if !migrator.HasIndex(&mailerdomain.DeliveryLogEntry{}, "Campaign") {
+			if err := migrator.CreateIndex(&mailerdomain.DeliveryLogEntry{}, "Campaign"); err != nil {
+				return nil, fmt.Errorf("failed to index campaign in mail delivery log table: %w", err)
+			}
+		}
+	}
+
+	if !suppressionTableExists {
+		logger.Info("Starting mail suppression table migration...")
+		if err := db.AutoMigrate(&mailerdomain.Suppression{}); err != nil {
+			logger.Error("Mail suppression migration failed", zap.Error(err))
+			return nil, fmt.Errorf("failed to migrate mail suppression table: %w", err)
+		}
+		logger.Info("Mail suppression table migration completed successfully")
+	}
+
+	if !idempotencyTableExists {
+		logger.Info("Starting mail idempotency key table migration...")
+		if err := db.AutoMigrate(&mailerdomain.IdempotencyRecord{}); err != nil {
+			logger.Error("Mail idempotency key migration failed", zap.Error(err))
+			return nil, fmt.Errorf("failed to migrate mail idempotency key table: %w", err)
+		}
+		logger.Info("Mail idempotency key table migration completed successfully")
+	}
+
+	if !trackingTableExists {
+		logger.Info("Starting mail tracking event table migration...")
+		if err := db.AutoMigrate(&mailerdomain.TrackingEvent{}); err != nil {
+			logger.Error("Mail tracking event migration failed", zap.Error(err))
+			return nil, fmt.Errorf("failed to migrate mail tracking event table: %w", err)
+		}
+		logger.Info("Mail tracking event table migration completed successfully")
+	}
+
+	if newsletterTableExists && resourceTableExists && outboxTableExists && templateTableExists && deliveryLogTableExists && suppressionTableExists && idempotencyTableExists && trackingTableExists {
 		logger.Info("Database schema is already up to date")
 	}
 
 	return db, nil
 }
 
-func initializeServices(cfg *config.Config, logger *zap.Logger) (*mailerservices.MailerService, *newsletterservices.NewsletterService, *resourceservices.ResourceService, error) {
-	smtpMailer, err := mailerinfra.NewSMTPMailer(
-		cfg.SMTP.Host,
-		cfg.SMTP.Port,
-		cfg.SMTP.User,
-		cfg.SMTP.Pass,
-		cfg.SMTP.From,
-		cfg.SMTP.Secure,
+// newMessageBuilder creates the message builder shared by the transports, so headers,
+// DKIM signing and tracking are identical everywhere. Previews use an unsigned copy.
+func newMessageBuilder(cfg *config.Config, logger *zap.Logger) (*mailerinfra.MessageBuilder, error) {
+	var dkimSigner *mailerinfra.DKIMSigner
+	if cfg.DKIM.Enabled() {
+		signer, err := mailerinfra.LoadDKIMSigner(cfg.DKIM.Domain, cfg.DKIM.Selector, cfg.DKIM.PrivateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize DKIM signer: %w", err)
+		}
+		dkimSigner = signer
+		logger.Info("DKIM signing enabled", zap.String("domain", cfg.DKIM.Domain), zap.String("selector", cfg.DKIM.Selector))
+	}
+	var tracker *mailerinfra.LinkTracker
+	if cfg.Tracking.Enabled() {
+		tracker = mailerinfra.NewLinkTracker(cfg.Tracking.BaseURL, trackingSigner(cfg), cfg.Tracking.Default)
+		logger.Info("Open and click tracking enabled", zap.String("base_url", cfg.Tracking.BaseURL))
+	}
+	return mailerinfra.NewMessageBuilder(cfg.SMTP.From, cfg.SMTP.FromName).SignWith(dkimSigner).TrackWith(tracker), nil
+}
+
+// initializeTransport creates the mail transport selected by transport.driver.
+func initializeTransport(cfg *config.Config, builder *mailerinfra.MessageBuilder, logger *zap.Logger) (mailerdomain.MailerRepository, error) {
+	logger.Info("Using mail transport", zap.String("driver", cfg.Transport.Driver))
+	switch cfg.Transport.Driver {
+	case "", "smtp":
+		if len(cfg.SMTP.Relays) == 0 {
+			return newSMTPMailer(cfg.SMTP.SMTPServerConfig, cfg.SMTP, builder)
+		}
+
+		relays := make([]mailerinfra.Relay, 0, len(cfg.SMTP.Relays))
+		for _, relayCfg := range cfg.SMTP.Relays {
+			relayMailer, err := newSMTPMailer(relayCfg.SMTPServerConfig, cfg.SMTP, builder)
+			if err != nil {
+				return nil, fmt.Errorf("relay %s: %w", relayCfg.Name, err)
+			}
+			relays = append(relays, mailerinfra.Relay{
+				Name:     relayCfg.Name,
+				Priority: relayCfg.Priority,
+				Weight:   relayCfg.Weight,
+				Mailer:   relayMailer,
+			})
+		}
+		logger.Info("Routing mail over SMTP relays", zap.Int("relays", len(relays)))
+		return mailerinfra.NewRelayMailer(relays, cfg.SMTP.RelayFailureThreshold, cfg.SMTP.RelayProbeInterval)
+	case "sendmail":
+		return mailerinfra.NewSendmailMailer(cfg.Transport.SendmailPath, cfg.Transport.SendmailArgs, cfg.Transport.SendmailTimeout, builder), nil
+	case "file":
+		return mailerinfra.NewFileMailer(cfg.Transport.FileDir, builder)
+	case "memory":
+		logger.Warn("Memory mail transport selected, messages are not delivered and only the last 1000 are kept")
+		return mailerinfra.NewMemoryMailer(builder), nil
+	case "http":
+		return mailerinfra.NewHTTPMailer(cfg.Transport.HTTPEndpoint, cfg.Transport.HTTPToken, cfg.Transport.HTTPTimeout, builder)
+	}
+	return nil, fmt.Errorf("unknown mail transport driver %q", cfg.Transport.Driver)
+}
+
+func newSMTPMailer(server config.SMTPServerConfig, cfg config.SMTPConfig, builder *mailerinfra.MessageBuilder) (*mailerinfra.SMTPMailer, error) {
+	mailer, err := mailerinfra.NewSMTPMailer(
+		server.Host,
+		server.Port,
+		server.User,
+		server.Pass,
+		mailerinfra.TLSOptions{
+			Mode:       mailerinfra.TLSMode(server.TLSMode),
+			Secure:     server.Secure,
+			CAFile:     server.TLSCAFile,
+			ServerName: server.TLSServerName,
+			MinVersion: server.TLSMinVersion,
+		},
+		mailerinfra.PoolOptions{
+			Size:        cfg.PoolSize,
+			MaxMessages: cfg.MaxMessagesConn,
+			IdleTimeout: cfg.PoolIdleTimeout,
+		},
+		builder,
 	)
 	if err != nil {
-		return nil, nil, nil, fmt.Errorf("failed to initialize mailer: %w", err)
+		return nil, err
+	}
+	return mailer.WithTimeout(cfg.Timeout), nil
+}
+
+// appServices holds the application services wired up by initializeServices.
+type appServices struct {
+	mailer        *mailerservices.MailerService
+	templates     *mailerservices.TemplateService
+	suppressions  *mailerservices.SuppressionService
+	bounces       *mailerservices.BounceService
+	idempotency   *mailerservices.IdempotencyService
+	tracking      *mailerservices.TrackingService
+	preview       *mailerservices.PreviewService
+	dispatcher    *mailerservices.MailDispatcher
+	bounceMaildir *mailerinfra.MaildirWatcher // nil unless bounces.maildir is set
+	newsletter    *newsletterservices.NewsletterService
+	resource      *resourceservices.ResourceService
+}
+
+// start launches the background workers.
+func (s *appServices) start() {
+	s.dispatcher.Start()
+	if s.bounceMaildir != nil {
+		s.bounceMaildir.Start()
+	}
+}
+
+// stop stops the background workers, waiting at most until ctx expires.
+func (s *appServices) stop(ctx context.Context, logger *zap.Logger) {
+	if s.bounceMaildir != nil {
+		if err := s.bounceMaildir.Stop(ctx); err != nil {
+			logger.Error("Error while stopping bounce maildir watcher", zap.Error(err))
+		}
+	}
+	if err := s.dispatcher.Stop(ctx); err != nil {
+		logger.Error("Error while stopping mail dispatcher", zap.Error(err))
+	}
+}
+
+func initializeServices(cfg *config.Config, logger *zap.Logger) (*appServices, error) {
+	builder, err := newMessageBuilder(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	mailer, err := initializeTransport(cfg, builder, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize mailer: %w", err)
 	}
 
 	db, err := initializeDatabase(cfg, logger)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, err
+	}
+
+	addressValidator, err := sharedkernel.LoadAddressValidator(cfg.Addresses.DisposableDomainsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load disposable email domains: %w", err)
+	}
+	if cfg.Addresses.CheckDomains {
+		addressValidator.CheckDomainsWith(sharedkernel.NewDomainChecker(net.DefaultResolver, cfg.Addresses.DNSTimeout, cfg.Addresses.DNSCacheTTL, cfg.Addresses.RejectTypos, cfg.Addresses.DNSWorkers))
+		logger.Info("Email domain deliverability check enabled", zap.Bool("reject_typos", cfg.Addresses.RejectTypos))
 	}
 
-	mailerService := mailerservices.NewMailerService(smtpMailer)
+	outboxRepo := mailerinfra.NewPostgresOutboxRepository(db)
+	deliveryLogRepo := mailerinfra.NewPostgresDeliveryLogRepository(db)
+	suppressionRepo := mailerinfra.NewPostgresSuppressionRepository(db)
+	mailDispatcher := mailerservices.NewMailDispatcher(
+		outboxRepo,
+		deliveryLogRepo,
+		suppressionRepo,
+		mailer,
+		mailerdomain.RetryPolicy{
+			MaxAttempts: cfg.SMTP.MaxAttempts,
+			BaseDelay:   cfg.SMTP.RetryBackoff,
+			MaxDelay:    cfg.SMTP.RetryMaxBackoff,
+		},
+		cfg.MailQueue.Workers,
+		cfg.MailQueue.BatchSize,
+		cfg.MailQueue.PollInterval,
+		cfg.MailQueue.LockTimeout,
+	).WithRateLimiter(newSendRateLimiter(cfg.RateLimits), cfg.RateLimits.MaxWait)
+	mailerService := mailerservices.NewMailerService(
+		outboxRepo,
+		deliveryLogRepo,
+		suppressionRepo,
+		mailDispatcher,
+		cfg.MailQueue.BulkConcurrency,
+		mailerdomain.AttachmentLimits{
+			MaxSize:      cfg.Attachments.MaxSize,
+			MaxTotalSize: cfg.Attachments.MaxTotalSize,
+		},
+		addressValidator,
+	)
+	suppressionService := mailerservices.NewSuppressionService(suppressionRepo)
 	newsletterRepo := newsletterinfra.NewPostgresRepository(db)
-	newsletterService := newsletterservices.NewNewsletterService(newsletterRepo)
+	newsletterService := newsletterservices.NewNewsletterService(newsletterRepo, addressValidator)
 	resourceRepo := resourceinfra.NewPostgresRepository(db)
 	resourceService := resourceservices.NewResourceService(resourceRepo)
+	templateRepo := mailerinfra.NewPostgresTemplateRepository(db)
+	translator := mailerinfra.NewResourceTranslator(resourceService, cfg.Templates.FallbackLang)
+	templateService := mailerservices.NewTemplateService(templateRepo, translator, mailerService)
+
+	var unsubscriber mailerdomain.NewsletterUnsubscriber
+	if cfg.Bounces.UnsubscribeNewsletter {
+		unsubscriber = newsletterService
+	}
+	bounceService := mailerservices.NewBounceService(mailerinfra.NewDSNParser(), deliveryLogRepo, suppressionRepo, unsubscriber)
+
+	var bounceMaildir *mailerinfra.MaildirWatcher
+	if cfg.Bounces.Maildir != "" {
+		bounceMaildir, err = mailerinfra.NewMaildirWatcher(cfg.Bounces.Maildir, cfg.Bounces.PollInterval, func(raw []byte) error {
+			_, err := bounceService.ProcessDSN(raw)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to open bounce maildir: %w", err)
+		}
+	}
+
+	idempotencyService := mailerservices.NewIdempotencyService(mailerinfra.NewPostgresIdempotencyRepository(db), cfg.Idempotency.TTL, cfg.Idempotency.LockTimeout)
+
+	return &appServices{
+		mailer:        mailerService,
+		templates:     templateService,
+		suppressions:  suppressionService,
+		bounces:       bounceService,
+		idempotency:   idempotencyService,
+		tracking:      mailerservices.NewTrackingService(trackingSigner(cfg), mailerinfra.NewPostgresTrackingRepository(db), deliveryLogRepo),
+		preview:       mailerservices.NewPreviewService(builder.ForPreview(), mailerService, templateService, cfg.Transport.MaxMessageSize),
+		dispatcher:    mailDispatcher,
+		bounceMaildir: bounceMaildir,
+		newsletter:    newsletterService,
+		resource:      resourceService,
+	}, nil
+}
+
+// trackingSigner returns the signer of tracking tokens, or nil when tracking is not
+// configured so that no token is accepted.
+func trackingSigner(cfg *config.Config) *mailerdomain.TrackingSigner {
+	if !cfg.Tracking.Enabled() {
+		return nil
+	}
+	return mailerdomain.NewTrackingSigner(cfg.Tracking.Secret)
+}
 
-	return mailerService, newsletterService, resourceService, nil
+// bounceSigner returns the signer posted bounces are verified with, or nil when no
+// secret is configured so that none is accepted.
+func bounceSigner(cfg *config.Config) *mailerdomain.BounceSigner {
+	if cfg.Bounces.Secret == "" {
+		return nil
+	}
+	return mailerdomain.NewBounceSigner(cfg.Bounces.Secret)
+}
+
+// newSendRateLimiter builds the outgoing rate limiter from rate_limits; nil when no limit is set.
+func newSendRateLimiter(cfg config.RateLimitsConfig) *mailerservices.SendRateLimiter {
+	domains := make(map[string]mailerdomain.RateLimit, len(cfg.Domains))
+	for _, limit := range cfg.Domains {
+		domains[strings.ToLower(limit.Domain)] = mailerdomain.RateLimit{PerSecond: limit.Rate, Burst: limit.Burst}
+	}
+	return mailerservices.NewSendRateLimiter(mailerdomain.RateLimit{PerSecond: cfg.Global.Rate, Burst: cfg.Global.Burst}, domains)
 }
 
-func setupApplication(cfg *config.Config, logger *zap.Logger) (*fiber.App, error) {
+func setupApplication(cfg *config.Config, logger *zap.Logger) (*fiber.App, *appServices, error) {
 	app := fiber.New(fiber.Config{
 		IdleTimeout:  5 * time.Second,
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		Concurrency:  256 * 1024,
+		// Leave room for base64-encoded attachments plus the rest of the payload
+		BodyLimit: int(cfg.Attachments.MaxTotalSize/3*4) + 1<<20,
 	})
 
 	setupMiddlewares(app)
 
-	mailerService, newsletterService, resourceService, err := initializeServices(cfg, logger)
+	services, err := initializeServices(cfg, logger)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	healthHandler := mailerhandlers.NewHealthCheckHandler()
-	mailerHandler := mailerhandlers.NewMailerHandler(mailerService)
-	newsletterHandler := newsletterhandlers.NewNewsletterHandler(newsletterService)
-	resourceHandler := resourcehandlers.NewResourceHandler(resourceService)
-
-	router.SetupRoutes(app, healthHandler, mailerHandler, newsletterHandler, resourceHandler)
+	router.SetupRoutes(app, router.Handlers{
+		Health:      mailerhandlers.NewHealthCheckHandler(),
+		Mailer:      mailerhandlers.NewMailerHandler(services.mailer, services.templates),
+		Template:    mailerhandlers.NewTemplateHandler(services.templates),
+		Suppression: mailerhandlers.NewSuppressionHandler(services.suppressions),
+		Bounce:      mailerhandlers.NewBounceHandler(services.bounces, bounceSigner(cfg)),
+		Idempotency: mailerhandlers.NewIdempotencyHandler(services.idempotency),
+		Tracking:    mailerhandlers.NewTrackingHandler(services.tracking),
+		Preview:     mailerhandlers.NewPreviewHandler(services.preview),
+		Newsletter:  newsletterhandlers.NewNewsletterHandler(services.newsletter),
+		Resource:    resourcehandlers.NewResourceHandler(services.resource),
+	})
 	app.Get("/metrics", adaptor.HTTPHandler(promhttp.HandlerFor(registry, promhttp.HandlerOpts{})))
 
-	return app, nil
+	return app, services, nil
 }
 
-func gracefulShutdown(app *fiber.App, logger *zap.Logger) {
+func gracefulShutdown(app *fiber.App, services *appServices, logger *zap.Logger) {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
 
@@ -238,6 +555,10 @@ func gracefulShutdown(app *fiber.App, logger *zap.Logger) {
 		logger.Error("Error during server shutdown", zap.Error(err))
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	services.stop(ctx, logger)
+
 	logger.Info("Server shutdown completed")
 }
 
@@ -257,11 +578,13 @@ func main() {
 	initPrometheusMetrics()
 	logger.Info("Starting application...")
 
-	app, err := setupApplication(cfg, logger)
+	app, services, err := setupApplication(cfg, logger)
 	if err != nil {
 		logger.Fatal("Failed to setup application", zap.Error(err))
 	}
 
+	services.start()
+
 	logger.Info("Server starting", zap.String("port", cfg.Server.Port))
 	go func() {
 		if err := app.Listen(":" + cfg.Server.Port); err != nil {
@@ -269,5 +592,5 @@ func main() {
 		}
 	}()
 
-	gracefulShutdown(app, logger)
+	gracefulShutdown(app, services, logger)
 }
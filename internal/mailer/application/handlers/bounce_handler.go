@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+
+	"monolith-domain/internal/mailer/application/services"
+	"monolith-domain/internal/mailer/domain"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BounceSignatureHeader carries the signature of a posted notification
+const BounceSignatureHeader = "X-Bounce-Signature"
+
+// BounceHandler accepts delivery status notifications, e.g. piped in by the MTA
+// receiving mail for the sender address
+type BounceHandler struct {
+	bounceService *services.BounceService
+	signer        *domain.BounceSigner
+}
+
+// NewBounceHandler initializes a new BounceHandler. Notifications must be signed by
+// signer; with a nil signer every notification is refused.
+func NewBounceHandler(bounceService *services.BounceService, signer *domain.BounceSigner) *BounceHandler {
+	return &BounceHandler{
+		bounceService: bounceService,
+		signer:        signer,
+	}
+}
+
+// ProcessBounce handles a raw RFC 3464 notification sent as the request body
+func (h *BounceHandler) ProcessBounce(c *fiber.Ctx) error {
+	if err := h.signer.Verify(c.Body(), c.Get(BounceSignatureHeader)); err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if len(c.Body()) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Request body must be the raw bounce message",
+		})
+	}
+
+	result, err := h.bounceService.ProcessDSN(c.Body())
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidDSN) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to process bounce",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Bounce processed",
+		"data":    result,
+	})
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+
+	"monolith-domain/internal/mailer/application/services"
+	"monolith-domain/internal/mailer/domain"
+	"monolith-domain/pkg/observability"
+
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// maxIdempotencyKeyLength bounds the Idempotency-Key header to the stored column size.
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyHandler is a middleware honouring the Idempotency-Key header on the
+// send endpoints. Requests without the header are passed through unchanged.
+type IdempotencyHandler struct {
+	idempotencyService *services.IdempotencyService
+	logger             *zap.Logger
+}
+
+// NewIdempotencyHandler initializes a new IdempotencyHandler
+func NewIdempotencyHandler(idempotencyService *services.IdempotencyService) *IdempotencyHandler {
+	return &IdempotencyHandler{
+		idempotencyService: idempotencyService,
+		logger:             observability.GetLogger(),
+	}
+}
+
+// Handle replays the stored response for a repeated key, or runs the route and stores
+// its response. Server errors are not stored, so such requests can be retried.
+func (h *IdempotencyHandler) Handle(c *fiber.Ctx) error {
+	key := c.Get("Idempotency-Key")
+	if key == "" {
+		return c.Next()
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Idempotency-Key must not be longer than 255 characters",
+		})
+	}
+
+	scope := c.Method() + " " + c.Route().Path
+	record, err := h.idempotencyService.Begin(scope, key, c.Body())
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrIdempotencyKeyReused):
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case errors.Is(err, domain.ErrIdempotencyKeyInUse):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to check idempotency key",
+		})
+	}
+	if record.Completed() {
+		c.Set("Idempotent-Replayed", "true")
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Status(record.StatusCode).Send(record.Response)
+	}
+
+	stopHeartbeat := h.idempotencyService.Heartbeat(record)
+	err = c.Next()
+	stopHeartbeat()
+	if err != nil {
+		h.release(record)
+		return err
+	}
+
+	status := c.Response().StatusCode()
+	if status >= fiber.StatusInternalServerError {
+		h.release(record)
+		return nil
+	}
+	response := append([]byte(nil), c.Response().Body()...)
+	if err := h.idempotencyService.Complete(record, status, response); err != nil {
+		h.logFailure("Failed to store idempotent response", record, err)
+	}
+	return nil
+}
+
+func (h *IdempotencyHandler) release(reservation *domain.IdempotencyRecord) {
+	if err := h.idempotencyService.Release(reservation); err != nil {
+		h.logFailure("Failed to release idempotency key", reservation, err)
+	}
+}
+
+// logFailure logs a failure to store the outcome of a reservation. Losing the
+// reservation to a retry only means that retry's outcome stands.
+func (h *IdempotencyHandler) logFailure(message string, reservation *domain.IdempotencyRecord, err error) {
+	if errors.Is(err, domain.ErrIdempotencyReservationLost) {
+		h.logger.Warn("Idempotency key was taken over by a retry after its lock expired", zap.String("scope", reservation.Scope), zap.Error(err))
+		return
+	}
+	h.logger.Error(message, zap.String("scope", reservation.Scope), zap.Error(err))
+}
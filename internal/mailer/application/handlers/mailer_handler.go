@@ -1,38 +1,200 @@
 package handlers
 
 import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"strings"
+	"time"
+
 	"monolith-domain/internal/mailer/application/services"
+	"monolith-domain/internal/mailer/domain"
+	"monolith-domain/internal/sharedkernel"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 // MailerHandler handles email-related requests
 type MailerHandler struct {
-	mailerService *services.MailerService
+	mailerService   *services.MailerService
+	templateService *services.TemplateService
 }
 
 // NewMailerHandler initializes a new MailerHandler
-func NewMailerHandler(mailerService *services.MailerService) *MailerHandler {
+func NewMailerHandler(mailerService *services.MailerService, templateService *services.TemplateService) *MailerHandler {
 	return &MailerHandler{
-		mailerService: mailerService,
+		mailerService:   mailerService,
+		templateService: templateService,
 	}
 }
 
+// SendMailRequest carries the email content either as html_body/text_body (sent as
+// multipart/alternative when both are given) or as the older body + is_html pair.
+// A text version is derived automatically when only HTML is supplied.
+// SendAt schedules the email; it is an RFC 3339 timestamp with a zone offset.
 type SendMailRequest struct {
-	To      string `json:"to"`
-	Subject string `json:"subject"`
-	Body    string `json:"body"`
-	IsHTML  bool   `json:"is_html"`
+	To          AddressList         `json:"to" form:"to"`
+	Cc          AddressList         `json:"cc" form:"cc"`
+	Bcc         AddressList         `json:"bcc" form:"bcc"`
+	ReplyTo     string              `json:"reply_to" form:"reply_to"`
+	FromName    string              `json:"from_name" form:"from_name"`
+	Headers     map[string]string   `json:"headers" form:"-"`
+	Subject     string              `json:"subject" form:"subject"`
+	Body        string              `json:"body" form:"body"`
+	IsHTML      bool                `json:"is_html" form:"is_html"`
+	HTMLBody    string              `json:"html_body" form:"html_body"`
+	TextBody    string              `json:"text_body" form:"text_body"`
+	Attachments []AttachmentRequest `json:"attachments" form:"-"`
+	SendAt      string              `json:"send_at" form:"send_at"`
+	Campaign    string              `json:"campaign" form:"campaign"`
+	Track       *bool               `json:"track" form:"track"` // Open and click tracking; omitted uses the configured default
+}
+
+type RescheduleRequest struct {
+	SendAt string `json:"send_at"`
+}
+
+// parseSendAt parses a requested send time. The zone offset is mandatory so the time is
+// never guessed from the server's zone.
+func parseSendAt(value string) (time.Time, error) {
+	sendAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("send_at must be an RFC 3339 timestamp with a zone offset, e.g. 2024-05-01T09:00:00+02:00")
+	}
+	return sendAt, nil
+}
+
+// AddressList accepts either a single address or an array of addresses in JSON.
+type AddressList []string
+
+func (l *AddressList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		if single == "" {
+			*l = nil
+		} else {
+			*l = AddressList{single}
+		}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
 }
 
+// AttachmentRequest is a file sent as JSON. Content is base64 encoded; set ContentID
+// to embed the file inline and reference it from the HTML body as "cid:<content_id>".
+type AttachmentRequest struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Content     string `json:"content"`
+	ContentID   string `json:"content_id"`
+}
+
+// SendBulkEmailsRequest sends the same content to every recipient, unless it is
+// personalised: when a stored template is named or any recipient carries a name, lang
+// or data, the subject and bodies (or the template) are rendered per recipient.
 type SendBulkEmailsRequest struct {
-	Recipients []string `json:"recipients"`
-	Subject    string   `json:"subject"`
-	Body       string   `json:"body"`
-	IsHTML     bool     `json:"is_html"`
+	Recipients []BulkRecipientRequest `json:"recipients"`
+	Subject    string                 `json:"subject"`
+	Body       string                 `json:"body"`
+	IsHTML     bool                   `json:"is_html"`
+	HTMLBody   string                 `json:"html_body"`
+	TextBody   string                 `json:"text_body"`
+	Campaign   string                 `json:"campaign"`
+	Track      *bool                  `json:"track"`
+	Template   string                 `json:"template"` // Stored template rendered per recipient instead of subject and bodies
+	Lang       string                 `json:"lang"`     // Language of recipients without their own
+}
+
+// BulkRecipientRequest accepts either a bare address or an object with merge variables:
+// {"email": "...", "name": "...", "lang": "de", "data": {...}}.
+type BulkRecipientRequest struct {
+	domain.BulkRecipient
+}
+
+func (r *BulkRecipientRequest) UnmarshalJSON(data []byte) error {
+	var email string
+	if err := json.Unmarshal(data, &email); err == nil {
+		r.BulkRecipient = domain.BulkRecipient{Email: email}
+		return nil
+	}
+	return json.Unmarshal(data, &r.BulkRecipient)
+}
+
+// personalized reports whether the recipient carries anything to merge.
+func (r BulkRecipientRequest) personalized() bool {
+	return r.Name != "" || r.Lang != "" || len(r.Data) > 0
+}
+
+// bodies resolves the HTML and text bodies from the new fields or the legacy body/is_html pair.
+func bodies(body string, isHTML bool, htmlBody, textBody string) (string, string) {
+	if htmlBody == "" && textBody == "" {
+		if isHTML {
+			return body, ""
+		}
+		return "", body
+	}
+	return htmlBody, textBody
 }
 
-// SendMail handles sending a single email
+// invalidAddress reports whether err refuses a recipient address.
+func invalidAddress(err error) bool {
+	return errors.Is(err, domain.ErrInvalidEmail) || errors.Is(err, domain.ErrDisposableEmail) ||
+		errors.Is(err, domain.ErrUndeliverableDomain) || errors.Is(err, domain.ErrSuspectedTypo)
+}
+
+// badRequest responds 400 with the error, adding the address probably meant when a
+// recipient domain looks misspelt.
+func badRequest(c *fiber.Ctx, err error) error {
+	body := fiber.Map{
+		"error": err.Error(),
+	}
+	var domainErr *sharedkernel.DomainError
+	if errors.As(err, &domainErr) && domainErr.Suggestion != "" {
+		body["suggestion"] = domainErr.Suggestion
+	}
+	return c.Status(fiber.StatusBadRequest).JSON(body)
+}
+
+// mailFromRequest builds the mail described by a send request.
+func mailFromRequest(req SendMailRequest, attachments []domain.Attachment) domain.Mail {
+	htmlBody, textBody := bodies(req.Body, req.IsHTML, req.HTMLBody, req.TextBody)
+	return domain.Mail{
+		To:          req.To,
+		Cc:          req.Cc,
+		Bcc:         req.Bcc,
+		ReplyTo:     req.ReplyTo,
+		FromName:    req.FromName,
+		Headers:     req.Headers,
+		Subject:     req.Subject,
+		HTMLBody:    htmlBody,
+		TextBody:    textBody,
+		Attachments: attachments,
+		Campaign:    req.Campaign,
+		Track:       req.Track,
+	}
+}
+
+type PaginationResponse struct {
+	Data       interface{} `json:"data"`
+	Total      int64       `json:"total"`
+	Page       int         `json:"page"`
+	Size       int         `json:"size"`
+	TotalPages int         `json:"total_pages"`
+}
+
+// SendMail handles sending a single email.
+// It accepts JSON with base64 attachments, or a multipart form where files are uploaded
+// under "attachments" and inline images under "inline" (referenced by their filename as CID).
 func (h *MailerHandler) SendMail(c *fiber.Ctx) error {
 	var req SendMailRequest
 	if err := c.BodyParser(&req); err != nil {
@@ -41,17 +203,122 @@ func (h *MailerHandler) SendMail(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.mailerService.SendMail(req.To, req.Subject, req.Body, req.IsHTML); err != nil {
+	attachments, err := parseAttachments(c, req.Attachments)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var sendAt time.Time
+	if req.SendAt != "" {
+		if sendAt, err = parseSendAt(req.SendAt); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	mail := mailFromRequest(req, attachments)
+	var id uuid.UUID
+	if sendAt.IsZero() {
+		id, err = h.mailerService.SendMail(mail)
+	} else {
+		id, err = h.mailerService.ScheduleMail(mail, sendAt)
+	}
+	if err != nil {
+		switch {
+		case invalidAddress(err), errors.Is(err, domain.ErrNoRecipients), errors.Is(err, domain.ErrInvalidHeader), errors.Is(err, domain.ErrInvalidAttachment), errors.Is(err, domain.ErrInvalidSchedule):
+			return badRequest(c, err)
+		case errors.Is(err, domain.ErrAttachmentTooLarge):
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		case errors.Is(err, domain.ErrRecipientSuppressed):
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to send email",
+			"error": "Failed to queue email",
 		})
 	}
 
-	return c.JSON(fiber.Map{
-		"message": "Email sent successfully",
+	if !sendAt.IsZero() {
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"message": "Email scheduled for delivery",
+			"id":      id,
+			"send_at": sendAt,
+		})
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Email queued for delivery",
+		"id":      id,
 	})
 }
 
+// parseAttachments decodes JSON attachments or, for multipart requests, reads the uploaded files.
+func parseAttachments(c *fiber.Ctx, requested []AttachmentRequest) ([]domain.Attachment, error) {
+	attachments := make([]domain.Attachment, 0, len(requested))
+	for _, attachment := range requested {
+		content, err := base64.StdEncoding.DecodeString(attachment.Content)
+		if err != nil {
+			return nil, fmt.Errorf("attachment %q is not valid base64", attachment.Filename)
+		}
+		attachments = append(attachments, domain.Attachment{
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+			Content:     content,
+			ContentID:   attachment.ContentID,
+		})
+	}
+
+	if !strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		return attachments, nil
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, errors.New("invalid multipart form")
+	}
+	for _, field := range []struct {
+		name   string
+		inline bool
+	}{{"attachments", false}, {"inline", true}} {
+		for _, file := range form.File[field.name] {
+			attachment, err := readUploadedFile(file, field.inline)
+			if err != nil {
+				return nil, err
+			}
+			attachments = append(attachments, attachment)
+		}
+	}
+	return attachments, nil
+}
+
+func readUploadedFile(file *multipart.FileHeader, inline bool) (domain.Attachment, error) {
+	f, err := file.Open()
+	if err != nil {
+		return domain.Attachment{}, fmt.Errorf("failed to read uploaded file %q", file.Filename)
+	}
+	defer f.Close()
+
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return domain.Attachment{}, fmt.Errorf("failed to read uploaded file %q", file.Filename)
+	}
+
+	attachment := domain.Attachment{
+		Filename:    file.Filename,
+		ContentType: file.Header.Get(fiber.HeaderContentType),
+		Content:     content,
+	}
+	if inline {
+		attachment.ContentID = file.Filename
+	}
+	return attachment, nil
+}
+
 // SendBulkEmails handles sending emails to multiple recipients
 func (h *MailerHandler) SendBulkEmails(c *fiber.Ctx) error {
 	var req SendBulkEmailsRequest
@@ -61,13 +328,348 @@ func (h *MailerHandler) SendBulkEmails(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.mailerService.SendBulkEmails(req.Recipients, req.Subject, req.Body, req.IsHTML); err != nil {
+	htmlBody, textBody := bodies(req.Body, req.IsHTML, req.HTMLBody, req.TextBody)
+	mail := domain.Mail{
+		Subject:  req.Subject,
+		HTMLBody: htmlBody,
+		TextBody: textBody,
+		Campaign: req.Campaign,
+		Track:    req.Track,
+	}
+
+	personalized := req.Template != ""
+	recipients := make([]domain.BulkRecipient, len(req.Recipients))
+	emails := make([]string, len(req.Recipients))
+	for i, recipient := range req.Recipients {
+		recipients[i] = recipient.BulkRecipient
+		emails[i] = recipient.Email
+		personalized = personalized || recipient.personalized()
+	}
+
+	var results []domain.RecipientResult
+	var err error
+	switch {
+	case req.Template != "":
+		results, err = h.templateService.SendBulkTemplate(recipients, req.Template, req.Lang, mail)
+	case personalized:
+		results, err = h.templateService.SendBulkMerge(recipients, req.Lang, mail)
+	default:
+		results, err = h.mailerService.SendBulkEmails(emails, mail)
+	}
+	if err != nil {
+		var missingErr *domain.MissingVariablesError
+		if errors.As(err, &missingErr) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error":   err.Error(),
+				"missing": missingErr.Missing,
+			})
+		}
+		if errors.Is(err, domain.ErrInvalidHeader) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if personalized {
+			return templateError(c, err, "Failed to send bulk emails")
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to send bulk emails",
 		})
 	}
 
+	summary := map[domain.RecipientStatus]int{
+		domain.RecipientStatusSent:    0,
+		domain.RecipientStatusFailed:  0,
+		domain.RecipientStatusSkipped: 0,
+		domain.RecipientStatusQueued:  0,
+	}
+	for _, result := range results {
+		summary[result.Status]++
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Bulk email processed",
+		"summary": summary,
+		"results": results,
+	})
+}
+
+// GetMessage returns the delivery status of a queued email
+func (h *MailerHandler) GetMessage(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+
+	message, err := h.mailerService.GetMessage(id)
+	if err != nil {
+		if errors.Is(err, domain.ErrMessageNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Message not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch message",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": message,
+	})
+}
+
+// GetDeadLetters lists messages that permanently failed or ran out of retries
+func (h *MailerHandler) GetDeadLetters(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	size := c.QueryInt("size", 10)
+
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 {
+		size = 10
+	}
+
+	messages, total, err := h.mailerService.GetDeadLetters(page, size)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch dead letters",
+		})
+	}
+
+	totalPages := int((total + int64(size) - 1) / int64(size))
+
+	return c.JSON(PaginationResponse{
+		Data:       messages,
+		Total:      total,
+		Page:       page,
+		Size:       size,
+		TotalPages: totalPages,
+	})
+}
+
+// SearchDeliveries searches the delivery log by recipient, message, status, campaign and the
+// time a message was queued. from and to accept RFC 3339 timestamps or dates
+// (YYYY-MM-DD); a date in to includes that whole day.
+func (h *MailerHandler) SearchDeliveries(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	size := c.QueryInt("size", 10)
+
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 {
+		size = 10
+	}
+
+	filter := domain.DeliveryLogFilter{
+		Recipient: c.Query("recipient"),
+		Status:    domain.DeliveryStatus(c.Query("status")),
+		Campaign:  c.Query("campaign"),
+		Page:      page,
+		Size:      size,
+	}
+
+	switch filter.Status {
+	case "", domain.DeliveryStatusQueued, domain.DeliveryStatusScheduled, domain.DeliveryStatusRetrying, domain.DeliveryStatusSent,
+		domain.DeliveryStatusFailed, domain.DeliveryStatusCancelled, domain.DeliveryStatusBounced:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid status",
+		})
+	}
+
+	if messageID := c.Query("message_id"); messageID != "" {
+		id, err := uuid.Parse(messageID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid message_id format",
+			})
+		}
+		filter.MessageID = id
+	}
+
+	var err error
+	if filter.From, err = parseLogTime(c.Query("from"), false); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid from, use RFC 3339 or YYYY-MM-DD",
+		})
+	}
+	if filter.To, err = parseLogTime(c.Query("to"), true); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid to, use RFC 3339 or YYYY-MM-DD",
+		})
+	}
+
+	entries, total, err := h.mailerService.SearchDeliveries(filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to search delivery log",
+		})
+	}
+
+	totalPages := int((total + int64(size) - 1) / int64(size))
+
+	return c.JSON(PaginationResponse{
+		Data:       entries,
+		Total:      total,
+		Page:       page,
+		Size:       size,
+		TotalPages: totalPages,
+	})
+}
+
+// parseLogTime parses a search bound. A bare date used as an upper bound moves to the
+// start of the next day so the range includes the whole date.
+func parseLogTime(value string, upper bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+// RequeueMessage puts a dead-lettered message back into the delivery queue
+func (h *MailerHandler) RequeueMessage(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+
+	if err := h.mailerService.RequeueMessage(id); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrMessageNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Message not found",
+			})
+		case errors.Is(err, domain.ErrMessageNotDead):
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Only dead-lettered messages can be requeued",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to requeue message",
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Message requeued for delivery",
+		"id":      id,
+	})
+}
+
+// GetScheduledMessages lists messages waiting for their send time, soonest first
+func (h *MailerHandler) GetScheduledMessages(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	size := c.QueryInt("size", 10)
+
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 {
+		size = 10
+	}
+
+	messages, total, err := h.mailerService.GetScheduledMessages(page, size)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch scheduled messages",
+		})
+	}
+
+	totalPages := int((total + int64(size) - 1) / int64(size))
+
+	return c.JSON(PaginationResponse{
+		Data:       messages,
+		Total:      total,
+		Page:       page,
+		Size:       size,
+		TotalPages: totalPages,
+	})
+}
+
+// scheduleError maps errors of changing a scheduled message to HTTP responses
+func scheduleError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, domain.ErrMessageNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Message not found",
+		})
+	case errors.Is(err, domain.ErrMessageNotScheduled):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Only messages that are still scheduled can be changed",
+		})
+	case errors.Is(err, domain.ErrInvalidSchedule):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
+
+// RescheduleMessage moves the send time of a scheduled message
+func (h *MailerHandler) RescheduleMessage(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+
+	var req RescheduleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	sendAt, err := parseSendAt(req.SendAt)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := h.mailerService.RescheduleMessage(id, sendAt); err != nil {
+		return scheduleError(c, err, "Failed to reschedule message")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Message rescheduled",
+		"id":      id,
+		"send_at": sendAt,
+	})
+}
+
+// CancelMessage cancels a scheduled message before it is sent
+func (h *MailerHandler) CancelMessage(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+
+	if err := h.mailerService.CancelMessage(id); err != nil {
+		return scheduleError(c, err, "Failed to cancel message")
+	}
+
 	return c.JSON(fiber.Map{
-		"message": "Bulk emails sent successfully",
+		"message": "Message cancelled",
+		"id":      id,
 	})
 }
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+
+	"monolith-domain/internal/mailer/application/services"
+	"monolith-domain/internal/mailer/domain"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PreviewHandler returns emails as they would be sent, without sending them
+type PreviewHandler struct {
+	previewService *services.PreviewService
+}
+
+// NewPreviewHandler initializes a new PreviewHandler
+func NewPreviewHandler(previewService *services.PreviewService) *PreviewHandler {
+	return &PreviewHandler{
+		previewService: previewService,
+	}
+}
+
+// PreviewMail takes the /send-email payload and returns the built message
+func (h *PreviewHandler) PreviewMail(c *fiber.Ctx) error {
+	var req SendMailRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	attachments, err := parseAttachments(c, req.Attachments)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	preview, err := h.previewService.PreviewMail(mailFromRequest(req, attachments))
+	if err != nil {
+		if invalidAddress(err) || errors.Is(err, domain.ErrNoRecipients) || errors.Is(err, domain.ErrInvalidHeader) || errors.Is(err, domain.ErrInvalidAttachment) {
+			return badRequest(c, err)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to build email preview",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": preview,
+	})
+}
+
+// PreviewTemplate takes the /send-template payload and returns the built message
+func (h *PreviewHandler) PreviewTemplate(c *fiber.Ctx) error {
+	var req SendTemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	preview, err := h.previewService.PreviewTemplate(req.To, req.Template, req.Lang, req.Data)
+	if err != nil {
+		return templateError(c, err, "Failed to build email preview")
+	}
+
+	return c.JSON(fiber.Map{
+		"data": preview,
+	})
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"errors"
+
+	"monolith-domain/internal/mailer/application/services"
+	"monolith-domain/internal/mailer/domain"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// SuppressionHandler handles suppression list requests
+type SuppressionHandler struct {
+	suppressionService *services.SuppressionService
+}
+
+// NewSuppressionHandler initializes a new SuppressionHandler
+func NewSuppressionHandler(suppressionService *services.SuppressionService) *SuppressionHandler {
+	return &SuppressionHandler{
+		suppressionService: suppressionService,
+	}
+}
+
+type CreateSuppressionRequest struct {
+	Email  string                   `json:"email"`
+	Reason domain.SuppressionReason `json:"reason"` // hard_bounce, complaint, manual or unsubscribed
+	Detail string                   `json:"detail"`
+}
+
+type UpdateSuppressionRequest struct {
+	Reason domain.SuppressionReason `json:"reason"`
+	Detail string                   `json:"detail"`
+}
+
+// suppressionError maps suppression service errors to HTTP responses
+func suppressionError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, domain.ErrSuppressionNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Suppression not found",
+		})
+	case errors.Is(err, domain.ErrSuppressionExists):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Address is already suppressed",
+		})
+	case errors.Is(err, domain.ErrInvalidEmail), errors.Is(err, domain.ErrInvalidSuppressionReason):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
+
+// CreateSuppression handles adding an address to the suppression list
+func (h *SuppressionHandler) CreateSuppression(c *fiber.Ctx) error {
+	var req CreateSuppressionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if req.Reason == "" {
+		req.Reason = domain.SuppressionReasonManual
+	}
+
+	suppression, err := h.suppressionService.CreateSuppression(req.Email, req.Reason, req.Detail)
+	if err != nil {
+		return suppressionError(c, err, "Failed to create suppression")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Address suppressed successfully",
+		"data":    suppression,
+	})
+}
+
+// UpdateSuppression handles changing the reason or detail of a suppression
+func (h *SuppressionHandler) UpdateSuppression(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+
+	var req UpdateSuppressionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	suppression, err := h.suppressionService.UpdateSuppression(id, req.Reason, req.Detail)
+	if err != nil {
+		return suppressionError(c, err, "Failed to update suppression")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Suppression updated successfully",
+		"data":    suppression,
+	})
+}
+
+// DeleteSuppression handles removing an address from the suppression list
+func (h *SuppressionHandler) DeleteSuppression(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+
+	if err := h.suppressionService.DeleteSuppression(id); err != nil {
+		return suppressionError(c, err, "Failed to delete suppression")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Suppression deleted successfully",
+	})
+}
+
+// GetSuppressionByID handles fetching a single suppression
+func (h *SuppressionHandler) GetSuppressionByID(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+
+	suppression, err := h.suppressionService.GetSuppressionByID(id)
+	if err != nil {
+		return suppressionError(c, err, "Failed to fetch suppression")
+	}
+
+	return c.JSON(fiber.Map{
+		"data": suppression,
+	})
+}
+
+// GetAllSuppressions handles listing suppressions with pagination. With an email
+// query parameter it looks that address up instead.
+func (h *SuppressionHandler) GetAllSuppressions(c *fiber.Ctx) error {
+	if email := c.Query("email"); email != "" {
+		suppression, err := h.suppressionService.GetSuppressionByEmail(email)
+		if err != nil {
+			return suppressionError(c, err, "Failed to fetch suppression")
+		}
+		return c.JSON(fiber.Map{
+			"data": suppression,
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	size := c.QueryInt("size", 10)
+
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 {
+		size = 10
+	}
+
+	suppressions, total, err := h.suppressionService.GetAllSuppressions(domain.SuppressionReason(c.Query("reason")), page, size)
+	if err != nil {
+		return suppressionError(c, err, "Failed to fetch suppressions")
+	}
+
+	totalPages := int((total + int64(size) - 1) / int64(size))
+
+	return c.JSON(PaginationResponse{
+		Data:       suppressions,
+		Total:      total,
+		Page:       page,
+		Size:       size,
+		TotalPages: totalPages,
+	})
+}
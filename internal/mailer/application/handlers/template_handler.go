@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"errors"
+
+	"monolith-domain/internal/mailer/application/services"
+	"monolith-domain/internal/mailer/domain"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// TemplateHandler handles email template requests
+type TemplateHandler struct {
+	templateService *services.TemplateService
+}
+
+// NewTemplateHandler initializes a new TemplateHandler
+func NewTemplateHandler(templateService *services.TemplateService) *TemplateHandler {
+	return &TemplateHandler{
+		templateService: templateService,
+	}
+}
+
+type CreateTemplateRequest struct {
+	Name     string `json:"name"`
+	Subject  string `json:"subject"`
+	HTMLBody string `json:"html_body"`
+	TextBody string `json:"text_body"`
+}
+
+type UpdateTemplateRequest struct {
+	Subject  string `json:"subject"`
+	HTMLBody string `json:"html_body"`
+	TextBody string `json:"text_body"`
+}
+
+type SendTemplateRequest struct {
+	To       string                 `json:"to"`
+	Template string                 `json:"template"`
+	Lang     string                 `json:"lang"` // Recipient language for {{t "key"}} lookups
+	Data     map[string]interface{} `json:"data"`
+}
+
+// templateError maps template service errors to HTTP responses
+func templateError(c *fiber.Ctx, err error, fallback string) error {
+	switch {
+	case errors.Is(err, domain.ErrTemplateNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Template not found",
+		})
+	case errors.Is(err, domain.ErrTemplateExists):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Template with this name already exists",
+		})
+	case invalidAddress(err), errors.Is(err, domain.ErrInvalidTemplate), errors.Is(err, domain.ErrNoRecipients), errors.Is(err, domain.ErrInvalidHeader):
+		return badRequest(c, err)
+	case errors.Is(err, domain.ErrTemplateRender), errors.Is(err, domain.ErrTranslationNotFound), errors.Is(err, domain.ErrRecipientSuppressed):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fallback,
+	})
+}
+
+// CreateTemplate handles creating a new email template
+func (h *TemplateHandler) CreateTemplate(c *fiber.Ctx) error {
+	var req CreateTemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	template, err := h.templateService.CreateTemplate(req.Name, req.Subject, req.HTMLBody, req.TextBody)
+	if err != nil {
+		return templateError(c, err, "Failed to create template")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Template created successfully",
+		"data":    template,
+	})
+}
+
+// UpdateTemplate handles updating an existing email template
+func (h *TemplateHandler) UpdateTemplate(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+
+	var req UpdateTemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	template, err := h.templateService.UpdateTemplate(id, req.Subject, req.HTMLBody, req.TextBody)
+	if err != nil {
+		return templateError(c, err, "Failed to update template")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Template updated successfully",
+		"data":    template,
+	})
+}
+
+// DeleteTemplate handles deleting an email template
+func (h *TemplateHandler) DeleteTemplate(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+
+	if err := h.templateService.DeleteTemplate(id); err != nil {
+		return templateError(c, err, "Failed to delete template")
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "Template deleted successfully",
+	})
+}
+
+// GetTemplateByID handles fetching a single email template
+func (h *TemplateHandler) GetTemplateByID(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid ID format",
+		})
+	}
+
+	template, err := h.templateService.GetTemplateByID(id)
+	if err != nil {
+		return templateError(c, err, "Failed to fetch template")
+	}
+
+	return c.JSON(fiber.Map{
+		"data": template,
+	})
+}
+
+// GetAllTemplates handles listing email templates with pagination
+func (h *TemplateHandler) GetAllTemplates(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	size := c.QueryInt("size", 10)
+
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 {
+		size = 10
+	}
+
+	templates, total, err := h.templateService.GetAllTemplates(page, size)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch templates",
+		})
+	}
+
+	totalPages := int((total + int64(size) - 1) / int64(size))
+
+	return c.JSON(PaginationResponse{
+		Data:       templates,
+		Total:      total,
+		Page:       page,
+		Size:       size,
+		TotalPages: totalPages,
+	})
+}
+
+// SendTemplate handles rendering a template and queueing the resulting email
+func (h *TemplateHandler) SendTemplate(c *fiber.Ctx) error {
+	var req SendTemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	id, err := h.templateService.SendTemplate(req.To, req.Template, req.Lang, req.Data)
+	if err != nil {
+		return templateError(c, err, "Failed to queue email")
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Email queued for delivery",
+		"id":      id,
+	})
+}
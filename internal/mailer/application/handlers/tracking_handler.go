@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"strings"
+
+	"monolith-domain/internal/mailer/application/services"
+	"monolith-domain/internal/mailer/domain"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// transparentGIF is the 1x1 tracking pixel.
+var transparentGIF = []byte{
+	0x47, 0x49, 0x46, 0x38, 0x39, 0x61, 0x01, 0x00, 0x01, 0x00, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff, 0x21, 0xf9, 0x04, 0x01, 0x00, 0x00, 0x00, 0x00, 0x2c, 0x00, 0x00, 0x00, 0x00,
+	0x01, 0x00, 0x01, 0x00, 0x00, 0x02, 0x02, 0x44, 0x01, 0x00, 0x3b,
+}
+
+// TrackingHandler serves the public open and click tracking endpoints and the
+// engagement statistics
+type TrackingHandler struct {
+	trackingService *services.TrackingService
+}
+
+// NewTrackingHandler initializes a new TrackingHandler
+func NewTrackingHandler(trackingService *services.TrackingService) *TrackingHandler {
+	return &TrackingHandler{
+		trackingService: trackingService,
+	}
+}
+
+// TrackOpen records an open and returns the tracking pixel. The pixel is served even
+// for unknown tokens so mail clients never show a broken image.
+func (h *TrackingHandler) TrackOpen(c *fiber.Ctx) error {
+	token := strings.TrimSuffix(c.Params("token"), ".gif")
+	_ = h.trackingService.RecordOpen(token, c.IP(), c.Get(fiber.HeaderUserAgent))
+
+	c.Set(fiber.HeaderCacheControl, "no-store, no-cache, must-revalidate, max-age=0")
+	c.Set(fiber.HeaderContentType, "image/gif")
+	return c.Send(transparentGIF)
+}
+
+// TrackClick records a click and redirects to the original link
+func (h *TrackingHandler) TrackClick(c *fiber.Ctx) error {
+	target, err := h.trackingService.RecordClick(c.Params("token"), c.IP(), c.Get(fiber.HeaderUserAgent))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Link not found",
+		})
+	}
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Redirect(target, fiber.StatusFound)
+}
+
+// GetStats returns opens and clicks, optionally restricted to a message, campaign or template
+func (h *TrackingHandler) GetStats(c *fiber.Ctx) error {
+	filter := domain.TrackingFilter{
+		Campaign: c.Query("campaign"),
+		Template: c.Query("template"),
+	}
+	if messageID := c.Query("message_id"); messageID != "" {
+		id, err := uuid.Parse(messageID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid message_id format",
+			})
+		}
+		filter.MessageID = id
+	}
+
+	stats, err := h.trackingService.GetStats(filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch tracking statistics",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data": stats,
+	})
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"monolith-domain/internal/mailer/domain"
+	"monolith-domain/pkg/observability"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// BounceService processes delivery status notifications returned to the sender
+// address. Failed recipients are marked bounced in the delivery log; hard bounces are
+// also suppressed and, when an unsubscriber is set, removed from the newsletter.
+type BounceService struct {
+	parser       domain.BounceParser
+	deliveryLog  domain.DeliveryLogRepository
+	suppressions domain.SuppressionRepository
+	unsubscriber domain.NewsletterUnsubscriber
+	logger       *zap.Logger
+}
+
+// BounceResult summarizes what a notification changed.
+type BounceResult struct {
+	Bounces     []domain.Bounce `json:"bounces"`
+	Logged      int             `json:"logged"`       // delivery log entries marked bounced
+	HardBounces int             `json:"hard_bounces"` // recipients suppressed
+}
+
+// NewBounceService initializes a BounceService. unsubscriber may be nil to leave
+// newsletter subscriptions alone.
+func NewBounceService(parser domain.BounceParser, deliveryLog domain.DeliveryLogRepository, suppressions domain.SuppressionRepository, unsubscriber domain.NewsletterUnsubscriber) *BounceService {
+	return &BounceService{
+		parser:       parser,
+		deliveryLog:  deliveryLog,
+		suppressions: suppressions,
+		unsubscriber: unsubscriber,
+		logger:       observability.GetLogger(),
+	}
+}
+
+// ProcessDSN applies a raw RFC 3464 notification. Reports other than failures, such
+// as delays, are returned but change nothing. Errors from storage are returned so the
+// caller can retry the notification; applying it twice is harmless.
+func (s *BounceService) ProcessDSN(raw []byte) (*BounceResult, error) {
+	bounces, err := s.parser.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &BounceResult{Bounces: bounces}
+	for _, bounce := range bounces {
+		if !bounce.Failed() {
+			continue
+		}
+
+		if bounce.MessageID != uuid.Nil {
+			logged, err := s.deliveryLog.MarkBounced(bounce.MessageID, bounce.Recipient, bounce.Summary())
+			if err != nil {
+				return nil, err
+			}
+			if logged {
+				result.Logged++
+			}
+		}
+
+		if !bounce.Hard() {
+			continue
+		}
+		suppressed, err := s.suppress(bounce)
+		if err != nil {
+			return nil, err
+		}
+		if suppressed {
+			result.HardBounces++
+		}
+	}
+
+	s.logger.Info("Delivery status notification processed",
+		zap.Int("recipients", len(result.Bounces)),
+		zap.Int("logged", result.Logged),
+		zap.Int("hard_bounces", result.HardBounces),
+	)
+	return result, nil
+}
+
+// suppress adds a hard-bounced recipient to the suppression list and unsubscribes it
+// from the newsletter. It returns false for addresses that cannot be suppressed.
+func (s *BounceService) suppress(bounce domain.Bounce) (bool, error) {
+	suppression, err := domain.NewSuppression(bounce.Recipient, domain.SuppressionReasonHardBounce, bounce.Summary())
+	if err != nil {
+		// An address we cannot suppress is not worth retrying the notification for
+		s.logger.Warn("Cannot suppress bounced recipient", zap.String("recipient", bounce.Recipient), zap.Error(err))
+		return false, nil
+	}
+	if bounce.MessageID != uuid.Nil {
+		id := bounce.MessageID
+		suppression.MessageID = &id
+	}
+	if err := s.suppressions.CreateIfMissing(suppression); err != nil {
+		return false, err
+	}
+
+	if s.unsubscriber != nil {
+		if err := s.unsubscriber.UnsubscribeEmail(suppression.Email); err != nil {
+			return false, err
+		}
+	}
+
+	s.logger.Info("Recipient suppressed after hard bounce",
+		zap.String("recipient", suppression.Email),
+		zap.String("status", bounce.Status),
+	)
+	return true, nil
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"monolith-domain/internal/mailer/domain"
+	"monolith-domain/pkg/observability"
+
+	"go.uber.org/zap"
+)
+
+// idempotencyPurgeInterval is how often expired keys are removed from the store.
+const idempotencyPurgeInterval = time.Hour
+
+// IdempotencyService keeps the responses of requests sent with an Idempotency-Key for
+// the configured window, so retried requests are answered without sending again.
+// A key is locked while its first request runs and the lock is renewed as long as the
+// request is alive; a lock not renewed within the lock timeout belongs to a request
+// that was lost, e.g. in a crash, and is taken over by a retry.
+type IdempotencyService struct {
+	repo        domain.IdempotencyRepository
+	ttl         time.Duration
+	lockTimeout time.Duration
+	logger      *zap.Logger
+
+	mu        sync.Mutex
+	lastPurge time.Time
+}
+
+func NewIdempotencyService(repo domain.IdempotencyRepository, ttl, lockTimeout time.Duration) *IdempotencyService {
+	return &IdempotencyService{
+		repo:        repo,
+		ttl:         ttl,
+		lockTimeout: lockTimeout,
+		logger:      observability.GetLogger(),
+	}
+}
+
+// Begin reserves key for a request with the given body. It returns either the new
+// reservation, which the caller processes the request under and passes to Complete
+// or Release, or the stored record whose response should be replayed, told apart by
+// Completed. ErrIdempotencyKeyInUse and ErrIdempotencyKeyReused are returned when
+// the first request is still running or had a different body.
+func (s *IdempotencyService) Begin(scope, key string, body []byte) (*domain.IdempotencyRecord, error) {
+	s.purgeExpired()
+
+	sum := sha256.Sum256(body)
+	// Postgres keeps microseconds; the reservation is matched on created_at later
+	now := time.Now().Truncate(time.Microsecond)
+	record := &domain.IdempotencyRecord{
+		Key:         key,
+		Scope:       scope,
+		RequestHash: hex.EncodeToString(sum[:]),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.lockTimeout),
+	}
+
+	reserved, err := s.repo.Reserve(record)
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return record, nil
+	}
+
+	existing, err := s.repo.Find(scope, key)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case existing == nil:
+		// Released by the first request in the meantime; let the client retry
+		return nil, domain.ErrIdempotencyKeyInUse
+	case existing.RequestHash != record.RequestHash:
+		return nil, domain.ErrIdempotencyKeyReused
+	case !existing.Completed():
+		return nil, domain.ErrIdempotencyKeyInUse
+	}
+	return existing, nil
+}
+
+// Heartbeat keeps the lock on reservation from expiring while its request runs, by
+// renewing it every third of the lock timeout. Call the returned function before
+// Complete or Release to stop renewing.
+func (s *IdempotencyService) Heartbeat(reservation *domain.IdempotencyRecord) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(s.lockTimeout / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			err := s.repo.Extend(reservation, time.Now().Add(s.lockTimeout))
+			if errors.Is(err, domain.ErrIdempotencyReservationLost) {
+				s.logger.Warn("Idempotency key was taken over while its request was running", zap.String("scope", reservation.Scope))
+				return
+			}
+			if err != nil {
+				s.logger.Error("Failed to renew idempotency key lock", zap.String("scope", reservation.Scope), zap.Error(err))
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// Complete stores the response to replay for the key of reservation for the replay window.
+func (s *IdempotencyService) Complete(reservation *domain.IdempotencyRecord, statusCode int, response []byte) error {
+	reservation.StatusCode = statusCode
+	reservation.Response = response
+	reservation.ExpiresAt = time.Now().Add(s.ttl)
+	return s.repo.Complete(reservation)
+}
+
+// Release frees the key of reservation so the request can be retried, e.g. after a
+// server error.
+func (s *IdempotencyService) Release(reservation *domain.IdempotencyRecord) error {
+	return s.repo.Delete(reservation)
+}
+
+// purgeExpired removes expired keys in the background at most once per purge interval.
+func (s *IdempotencyService) purgeExpired() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastPurge) < idempotencyPurgeInterval {
+		return
+	}
+	s.lastPurge = now
+
+	go func() {
+		deleted, err := s.repo.DeleteExpired(now)
+		if err != nil {
+			s.logger.Error("Failed to purge expired idempotency keys", zap.Error(err))
+			return
+		}
+		if deleted > 0 {
+			s.logger.Info("Purged expired idempotency keys", zap.Int64("deleted", deleted))
+		}
+	}()
+}
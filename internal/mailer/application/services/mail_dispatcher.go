@@ -0,0 +1,460 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"monolith-domain/internal/mailer/domain"
+	"monolith-domain/pkg/observability"
+
+	"go.uber.org/zap"
+)
+
+// errDeliveryDeferred is returned by deliver when a send rate limit postponed the message.
+var errDeliveryDeferred = errors.New("send rate limit reached, message deferred")
+
+// MailDispatcher drains the outbox in the background with a fixed pool of workers.
+// A single poller claims batches of pending messages and hands them to the workers,
+// so the HTTP handlers only ever write to the outbox.
+type MailDispatcher struct {
+	outbox       domain.OutboxRepository
+	deliveryLog  domain.DeliveryLogRepository
+	suppressions domain.SuppressionRepository
+	mailer       domain.MailerRepository
+	retry        domain.RetryPolicy
+	limiter      *SendRateLimiter
+	maxRateWait  time.Duration
+	workers      int
+	batchSize    int
+	pollInterval time.Duration
+	lockTimeout  time.Duration
+	logger       *zap.Logger
+
+	jobs     chan *domain.OutboxMessage
+	wake     chan struct{}
+	quit     chan struct{}
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+}
+
+// NewMailDispatcher initializes a dispatcher; call Start to launch the workers.
+func NewMailDispatcher(outbox domain.OutboxRepository, deliveryLog domain.DeliveryLogRepository, suppressions domain.SuppressionRepository, mailer domain.MailerRepository, retry domain.RetryPolicy, workers, batchSize int, pollInterval, lockTimeout time.Duration) *MailDispatcher {
+	return &MailDispatcher{
+		outbox:       outbox,
+		deliveryLog:  deliveryLog,
+		suppressions: suppressions,
+		mailer:       mailer,
+		retry:        retry,
+		workers:      workers,
+		batchSize:    batchSize,
+		pollInterval: pollInterval,
+		lockTimeout:  lockTimeout,
+		logger:       observability.GetLogger(),
+		jobs:         make(chan *domain.OutboxMessage, workers),
+		wake:         make(chan struct{}, 1),
+		quit:         make(chan struct{}),
+	}
+}
+
+// WithRateLimiter makes deliveries respect limiter. A message that would have to wait
+// longer than maxWait for its turn is put back into the outbox instead of holding a
+// worker. A nil limiter disables rate limiting.
+func (d *MailDispatcher) WithRateLimiter(limiter *SendRateLimiter, maxWait time.Duration) *MailDispatcher {
+	d.limiter = limiter
+	d.maxRateWait = maxWait
+	return d
+}
+
+// Start launches the poller and the worker pool.
+func (d *MailDispatcher) Start() {
+	for i := 0; i < d.workers; i++ {
+		d.wg.Add(1)
+		go d.work()
+	}
+	d.wg.Add(1)
+	go d.poll()
+
+	d.logger.Info("Mail dispatcher started",
+		zap.Int("workers", d.workers),
+		zap.Duration("poll_interval", d.pollInterval),
+	)
+}
+
+// Notify wakes the poller up early, e.g. right after a message was enqueued.
+func (d *MailDispatcher) Notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Stop stops claiming new messages and waits for in-flight deliveries to finish
+// or for ctx to expire. Messages left in processing are reclaimed after the lock timeout.
+func (d *MailDispatcher) Stop(ctx context.Context) error {
+	d.stopOnce.Do(func() { close(d.quit) })
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		d.logger.Info("Mail dispatcher stopped")
+		// Deliveries are over, so transports holding connections can release them
+		if closer, ok := d.mailer.(io.Closer); ok {
+			return closer.Close()
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *MailDispatcher) poll() {
+	defer d.wg.Done()
+	defer close(d.jobs)
+
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		d.drain()
+		select {
+		case <-d.quit:
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// drain keeps claiming batches until the outbox has no more due messages.
+func (d *MailDispatcher) drain() {
+	for {
+		select {
+		case <-d.quit:
+			return
+		default:
+		}
+
+		messages, err := d.outbox.ClaimPending(d.batchSize, d.lockTimeout)
+		if err != nil {
+			d.logger.Error("Failed to claim outbox messages", zap.Error(err))
+			return
+		}
+		for _, message := range messages {
+			d.jobs <- message
+		}
+		if len(messages) < d.batchSize {
+			return
+		}
+	}
+}
+
+func (d *MailDispatcher) work() {
+	defer d.wg.Done()
+	for message := range d.jobs {
+		d.deliver(message)
+	}
+}
+
+// deliver sends a claimed message and records the outcome in the outbox and the
+// delivery log. Transient failures are rescheduled with exponential backoff;
+// permanent failures and messages that ran out of attempts are dead-lettered.
+// Recipients suppressed since the message was queued are left out, and a message
+// left without recipients is dead-lettered.
+// Messages over the send rate are deferred and errDeliveryDeferred is returned.
+func (d *MailDispatcher) deliver(message *domain.OutboxMessage) error {
+	mail, err := message.Mail()
+	if err != nil {
+		d.deadLetter(message, nil, err)
+		return err
+	}
+
+	mail, suppressed, err := d.withoutSuppressed(mail)
+	if err != nil {
+		return d.failed(message, nil, fmt.Errorf("failed to check the suppression list: %w", err))
+	}
+	if len(mail.Recipients()) == 0 {
+		err := fmt.Errorf("%w: %s", domain.ErrRecipientSuppressed, strings.Join(sortedAddresses(suppressed), ", "))
+		d.deadLetter(message, nil, err)
+		return err
+	}
+
+	if !d.awaitRate(message, mail) {
+		return errDeliveryDeferred
+	}
+
+	receipt, err := d.mailer.Send(mail)
+	d.suppressRejected(message, receipt, err)
+	if err != nil {
+		return d.failed(message, receipt, err)
+	}
+
+	if d.recorded(message, d.outbox.MarkSent(message), "Failed to mark outbox message as sent") {
+		d.logDelivery(message, domain.DeliveryStatusSent, receipt, nil)
+		d.logSuppressed(message, suppressed)
+		d.logRejected(message, receipt)
+		d.retryDeferred(message, mail, receipt)
+	}
+	return nil
+}
+
+// failed reschedules message after a transient failure, or dead-letters it when err is
+// permanent or the message ran out of attempts. It returns err.
+func (d *MailDispatcher) failed(message *domain.OutboxMessage, receipt *domain.DeliveryReceipt, err error) error {
+	if domain.IsPermanent(err) || d.retry.Exhausted(message.Attempts) {
+		d.deadLetter(message, receipt, err)
+		return err
+	}
+
+	nextAttemptAt := time.Now().Add(d.retry.Backoff(message.Attempts))
+	d.logger.Warn("Outbox delivery failed, will retry",
+		zap.String("id", message.ID.String()),
+		zap.Int("attempts", message.Attempts),
+		zap.Time("next_attempt_at", nextAttemptAt),
+		zap.Error(err),
+	)
+	if d.recorded(message, d.outbox.MarkRetry(message, err.Error(), nextAttemptAt), "Failed to reschedule outbox message") {
+		d.logDelivery(message, domain.DeliveryStatusRetrying, receipt, err)
+	}
+	return err
+}
+
+// withoutSuppressed checks the recipients of mail against the suppression list again,
+// since addresses may have been suppressed after the message was queued. It returns
+// mail with the suppressed recipients left out of the envelope and their reasons keyed
+// by normalised address.
+func (d *MailDispatcher) withoutSuppressed(mail domain.Mail) (domain.Mail, map[string]domain.SuppressionReason, error) {
+	recipients := mail.Recipients()
+	suppressed, err := suppressedAddresses(d.suppressions, recipients)
+	if err != nil || len(suppressed) == 0 {
+		return mail, nil, err
+	}
+
+	mail.EnvelopeTo = make([]string, 0, len(recipients))
+	for _, address := range recipients {
+		if _, ok := suppressed[domain.NormalizeRecipient(address)]; !ok {
+			mail.EnvelopeTo = append(mail.EnvelopeTo, address)
+		}
+	}
+	return mail, suppressed, nil
+}
+
+// recorded logs a failure to record the outcome of message in the outbox. It returns
+// false when the claim was lost to another worker, whose outcome then stands and must
+// not be overwritten in the delivery log either.
+func (d *MailDispatcher) recorded(message *domain.OutboxMessage, err error, failure string) bool {
+	if errors.Is(err, domain.ErrClaimLost) {
+		d.logger.Warn("Outbox message was reclaimed by another worker, dropping this outcome",
+			zap.String("id", message.ID.String()),
+			zap.Int("attempts", message.Attempts),
+		)
+		return false
+	}
+	if err != nil {
+		d.logger.Error(failure, zap.String("id", message.ID.String()), zap.Error(err))
+	}
+	return true
+}
+
+// awaitRate takes the message's share of the send rate, waiting up to maxRateWait for
+// it. It returns false when the message was deferred instead.
+func (d *MailDispatcher) awaitRate(message *domain.OutboxMessage, mail domain.Mail) bool {
+	reservation := d.limiter.Reserve(mail.Recipients())
+	delay := reservation.Delay()
+	if delay == 0 {
+		return true
+	}
+
+	if delay <= d.maxRateWait {
+		observability.RecordRateLimited(reservation.Limiter(), "wait")
+		time.Sleep(delay)
+		return true
+	}
+
+	// Give the tokens back so messages that can go sooner are not held up by this one
+	reservation.Cancel()
+	observability.RecordRateLimited(reservation.Limiter(), "defer")
+	until := time.Now().Add(delay)
+	d.logger.Debug("Send rate limit reached, deferring message",
+		zap.String("id", message.ID.String()),
+		zap.String("limiter", reservation.Limiter()),
+		zap.Time("until", until),
+	)
+	d.recorded(message, d.outbox.Defer(message, until), "Failed to defer outbox message")
+	return false
+}
+
+// logDelivery records an attempt in the delivery log. A failure to write the log is
+// logged but never changes the delivery outcome.
+func (d *MailDispatcher) logDelivery(message *domain.OutboxMessage, status domain.DeliveryStatus, receipt *domain.DeliveryReceipt, sendErr error) {
+	update := domain.DeliveryUpdate{Status: status, Attempts: message.Attempts, At: time.Now()}
+	if receipt != nil {
+		update.Transport = receipt.Transport
+		update.Response = receipt.Response
+	}
+	if sendErr != nil {
+		update.Response = sendErr.Error()
+	}
+
+	if err := d.deliveryLog.Update(message.ID, update); err != nil {
+		d.logger.Error("Failed to update delivery log", zap.String("id", message.ID.String()), zap.Error(err))
+	}
+}
+
+// logRejected marks the recipients the server refused for good, while it accepted the
+// message for the others, as failed in the delivery log.
+func (d *MailDispatcher) logRejected(message *domain.OutboxMessage, receipt *domain.DeliveryReceipt) {
+	if receipt == nil {
+		return
+	}
+	for _, rejection := range receipt.Rejected {
+		if domain.IsPermanent(rejection) {
+			d.logRecipientFailed(message, rejection.Recipient, receipt.Transport, rejection.Error())
+		}
+	}
+}
+
+// retryDeferred queues the recipients the server deferred with a transient reply, while
+// it accepted the message for the others, as a new outbox message for just them. The
+// retry keeps the attempt count and follows the usual backoff, and their delivery log
+// entries move over to it. Deferred recipients out of attempts are logged as failed.
+func (d *MailDispatcher) retryDeferred(message *domain.OutboxMessage, mail domain.Mail, receipt *domain.DeliveryReceipt) {
+	if receipt == nil {
+		return
+	}
+	var deferred []*domain.DeliveryError
+	for _, rejection := range receipt.Rejected {
+		if !domain.IsPermanent(rejection) {
+			deferred = append(deferred, rejection)
+		}
+	}
+	if len(deferred) == 0 {
+		return
+	}
+	if d.retry.Exhausted(message.Attempts) {
+		for _, rejection := range deferred {
+			d.logRecipientFailed(message, rejection.Recipient, receipt.Transport, rejection.Error())
+		}
+		return
+	}
+
+	mail.EnvelopeTo = make([]string, 0, len(deferred))
+	for _, rejection := range deferred {
+		mail.EnvelopeTo = append(mail.EnvelopeTo, rejection.Recipient)
+	}
+	retry, err := domain.NewOutboxMessage(mail)
+	if err != nil {
+		d.logger.Error("Failed to queue deferred recipients", zap.String("id", message.ID.String()), zap.Error(err))
+		return
+	}
+	retry.Attempts = message.Attempts
+	retry.NextAttemptAt = time.Now().Add(d.retry.Backoff(message.Attempts))
+
+	// The entries move first so the retry is never delivered without them
+	for _, rejection := range deferred {
+		update := domain.DeliveryUpdate{
+			Status:    domain.DeliveryStatusRetrying,
+			Attempts:  message.Attempts,
+			Transport: receipt.Transport,
+			Response:  rejection.Error(),
+			At:        time.Now(),
+		}
+		if err := d.deliveryLog.Reassign(message.ID, rejection.Recipient, retry.ID, update); err != nil {
+			d.logger.Error("Failed to update delivery log", zap.String("id", message.ID.String()), zap.String("recipient", rejection.Recipient), zap.Error(err))
+		}
+	}
+	if err := d.outbox.Enqueue(retry); err != nil {
+		d.logger.Error("Failed to queue deferred recipients", zap.String("id", message.ID.String()), zap.Error(err))
+		for _, rejection := range deferred {
+			d.logRecipientFailed(retry, rejection.Recipient, receipt.Transport, rejection.Error())
+		}
+		return
+	}
+	d.logger.Info("Deferred recipients queued for retry",
+		zap.String("id", message.ID.String()),
+		zap.String("retry_id", retry.ID.String()),
+		zap.Int("recipients", len(deferred)),
+		zap.Time("next_attempt_at", retry.NextAttemptAt),
+	)
+}
+
+// logSuppressed marks the recipients left out of a sent message because they were
+// suppressed as failed in the delivery log.
+func (d *MailDispatcher) logSuppressed(message *domain.OutboxMessage, suppressed map[string]domain.SuppressionReason) {
+	for _, address := range sortedAddresses(suppressed) {
+		d.logRecipientFailed(message, address, "", fmt.Sprintf("suppressed (%s)", suppressed[address]))
+	}
+}
+
+func (d *MailDispatcher) logRecipientFailed(message *domain.OutboxMessage, recipient, transport, response string) {
+	update := domain.DeliveryUpdate{
+		Status:    domain.DeliveryStatusFailed,
+		Attempts:  message.Attempts,
+		Transport: transport,
+		Response:  response,
+		At:        time.Now(),
+	}
+	if err := d.deliveryLog.UpdateRecipient(message.ID, recipient, update); err != nil {
+		d.logger.Error("Failed to update delivery log", zap.String("id", message.ID.String()), zap.String("recipient", recipient), zap.Error(err))
+	}
+}
+
+// suppressRejected adds every recipient refused with a permanent 5xx RCPT TO reply,
+// whether or not the message went to the others, to the suppression list as a hard
+// bounce. Existing entries are left as they are.
+func (d *MailDispatcher) suppressRejected(message *domain.OutboxMessage, receipt *domain.DeliveryReceipt, sendErr error) {
+	rejections := []error{sendErr}
+	if receipt != nil {
+		for _, rejection := range receipt.Rejected {
+			rejections = append(rejections, rejection)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, rejection := range rejections {
+		recipient, ok := domain.RejectedRecipient(rejection)
+		if ok && !seen[recipient] {
+			seen[recipient] = true
+			d.suppress(message, recipient, rejection)
+		}
+	}
+}
+
+func (d *MailDispatcher) suppress(message *domain.OutboxMessage, recipient string, err error) {
+	suppression, newErr := domain.NewSuppression(recipient, domain.SuppressionReasonHardBounce, err.Error())
+	if newErr != nil {
+		d.logger.Warn("Cannot suppress rejected recipient", zap.String("recipient", recipient), zap.Error(newErr))
+		return
+	}
+	id := message.ID
+	suppression.MessageID = &id
+
+	if err := d.suppressions.CreateIfMissing(suppression); err != nil {
+		d.logger.Error("Failed to suppress rejected recipient", zap.String("recipient", recipient), zap.Error(err))
+		return
+	}
+	d.logger.Info("Recipient suppressed after hard bounce",
+		zap.String("recipient", suppression.Email),
+		zap.String("id", message.ID.String()),
+	)
+}
+
+func (d *MailDispatcher) deadLetter(message *domain.OutboxMessage, receipt *domain.DeliveryReceipt, reason error) {
+	d.logger.Error("Outbox message dead-lettered",
+		zap.String("id", message.ID.String()),
+		zap.Int("attempts", message.Attempts),
+		zap.Error(reason),
+	)
+	if d.recorded(message, d.outbox.MarkDead(message, reason.Error()), "Failed to dead-letter outbox message") {
+		d.logDelivery(message, domain.DeliveryStatusFailed, receipt, reason)
+	}
+}
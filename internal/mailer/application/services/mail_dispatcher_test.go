@@ -0,0 +1,214 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"monolith-domain/internal/mailer/domain"
+
+	"github.com/google/uuid"
+)
+
+type fakeOutbox struct {
+	domain.OutboxRepository
+	status   domain.OutboxStatus
+	reason   string
+	enqueued []*domain.OutboxMessage
+}
+
+func (o *fakeOutbox) Enqueue(messages ...*domain.OutboxMessage) error {
+	o.enqueued = append(o.enqueued, messages...)
+	return nil
+}
+
+func (o *fakeOutbox) MarkSent(*domain.OutboxMessage) error {
+	o.status = domain.OutboxStatusSent
+	return nil
+}
+
+func (o *fakeOutbox) MarkRetry(_ *domain.OutboxMessage, reason string, _ time.Time) error {
+	o.status, o.reason = domain.OutboxStatusPending, reason
+	return nil
+}
+
+func (o *fakeOutbox) MarkDead(_ *domain.OutboxMessage, reason string) error {
+	o.status, o.reason = domain.OutboxStatusDead, reason
+	return nil
+}
+
+// fakeDeliveryLog keeps the status of every entry keyed by message ID and recipient.
+type fakeDeliveryLog struct {
+	domain.DeliveryLogRepository
+	entries map[uuid.UUID]map[string]domain.DeliveryStatus
+}
+
+func newFakeDeliveryLog(message *domain.OutboxMessage, mail domain.Mail) *fakeDeliveryLog {
+	log := &fakeDeliveryLog{entries: map[uuid.UUID]map[string]domain.DeliveryStatus{}}
+	for _, entry := range domain.NewDeliveryLogEntries(message, mail) {
+		log.entry(message.ID)[entry.Recipient] = entry.Status
+	}
+	return log
+}
+
+func (l *fakeDeliveryLog) entry(messageID uuid.UUID) map[string]domain.DeliveryStatus {
+	if l.entries[messageID] == nil {
+		l.entries[messageID] = map[string]domain.DeliveryStatus{}
+	}
+	return l.entries[messageID]
+}
+
+func (l *fakeDeliveryLog) Update(messageID uuid.UUID, update domain.DeliveryUpdate) error {
+	for recipient := range l.entries[messageID] {
+		l.entries[messageID][recipient] = update.Status
+	}
+	return nil
+}
+
+func (l *fakeDeliveryLog) UpdateRecipient(messageID uuid.UUID, recipient string, update domain.DeliveryUpdate) error {
+	if _, ok := l.entries[messageID][domain.NormalizeRecipient(recipient)]; ok {
+		l.entries[messageID][domain.NormalizeRecipient(recipient)] = update.Status
+	}
+	return nil
+}
+
+func (l *fakeDeliveryLog) Reassign(messageID uuid.UUID, recipient string, newMessageID uuid.UUID, update domain.DeliveryUpdate) error {
+	recipient = domain.NormalizeRecipient(recipient)
+	if _, ok := l.entries[messageID][recipient]; ok {
+		delete(l.entries[messageID], recipient)
+		l.entry(newMessageID)[recipient] = update.Status
+	}
+	return nil
+}
+
+type fakeSuppressions struct {
+	domain.SuppressionRepository
+}
+
+func (fakeSuppressions) FindByEmails([]string) ([]*domain.Suppression, error) {
+	return nil, nil
+}
+
+func (fakeSuppressions) CreateIfMissing(*domain.Suppression) error {
+	return nil
+}
+
+// refusingMailer accepts a message for every recipient except those in refused, which
+// it reports the way the SMTP mailer does for RCPT TO refusals.
+type refusingMailer struct {
+	refused map[string]int // address -> reply code
+	sent    [][]string
+}
+
+func (m *refusingMailer) Send(mail domain.Mail) (*domain.DeliveryReceipt, error) {
+	receipt := &domain.DeliveryReceipt{Transport: "test"}
+	var accepted []string
+	for _, recipient := range mail.Recipients() {
+		code, ok := m.refused[recipient]
+		if !ok {
+			accepted = append(accepted, recipient)
+			continue
+		}
+		receipt.Rejected = append(receipt.Rejected, &domain.DeliveryError{
+			Code:      code,
+			Recipient: recipient,
+			Permanent: code >= 500,
+			Err:       fmt.Errorf("%d refused", code),
+		})
+	}
+	m.sent = append(m.sent, accepted)
+	return receipt, nil
+}
+
+func TestMailDispatcherRetriesDeferredRecipients(t *testing.T) {
+	tests := []struct {
+		name          string
+		attempts      int
+		wantRetry     bool
+		wantLogStatus domain.DeliveryStatus // of the deferred recipient
+	}{
+		{name: "deferred recipient is queued again", attempts: 1, wantRetry: true, wantLogStatus: domain.DeliveryStatusRetrying},
+		{name: "deferred recipient out of attempts fails", attempts: 3, wantLogStatus: domain.DeliveryStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mail := domain.Mail{
+				To:       []string{"recipient@example.com"},
+				Cc:       []string{"refused@example.com"},
+				Bcc:      []string{"deferred@example.com"},
+				Subject:  "test",
+				TextBody: "hello",
+			}
+			message, err := domain.NewOutboxMessage(mail)
+			if err != nil {
+				t.Fatal(err)
+			}
+			message.Attempts = tt.attempts
+
+			outbox, deliveryLog := &fakeOutbox{}, newFakeDeliveryLog(message, mail)
+			mailer := &refusingMailer{refused: map[string]int{"refused@example.com": 550, "deferred@example.com": 450}}
+			retry := domain.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Minute, MaxDelay: time.Hour}
+			dispatcher := NewMailDispatcher(outbox, deliveryLog, fakeSuppressions{}, mailer, retry, 1, 1, time.Second, time.Minute)
+
+			if err := dispatcher.deliver(message); err != nil {
+				t.Fatal(err)
+			}
+			if outbox.status != domain.OutboxStatusSent {
+				t.Errorf("expected the message to be marked sent, got %q", outbox.status)
+			}
+			original := deliveryLog.entries[message.ID]
+			if original["recipient@example.com"] != domain.DeliveryStatusSent || original["refused@example.com"] != domain.DeliveryStatusFailed {
+				t.Errorf("unexpected log entries of the message: %v", original)
+			}
+
+			if !tt.wantRetry {
+				if len(outbox.enqueued) != 0 {
+					t.Fatalf("expected no retry, got %d queued messages", len(outbox.enqueued))
+				}
+				if original["deferred@example.com"] != tt.wantLogStatus {
+					t.Errorf("expected the deferred recipient to be %q, got %v", tt.wantLogStatus, original)
+				}
+				return
+			}
+
+			if len(outbox.enqueued) != 1 {
+				t.Fatalf("expected 1 retry, got %d queued messages", len(outbox.enqueued))
+			}
+			queued := outbox.enqueued[0]
+			retryMail, err := queued.Mail()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(retryMail.Recipients(), ","); got != "deferred@example.com" {
+				t.Errorf("expected the retry to go to the deferred recipient only, got %s", got)
+			}
+			if strings.Join(retryMail.To, ",") != "recipient@example.com" || strings.Join(retryMail.Cc, ",") != "refused@example.com" {
+				t.Errorf("expected the retry to keep the headers, got To %v and Cc %v", retryMail.To, retryMail.Cc)
+			}
+			if queued.Attempts != tt.attempts || !queued.NextAttemptAt.After(time.Now()) {
+				t.Errorf("expected the retry to keep %d attempts and wait for the backoff, got %d due at %v", tt.attempts, queued.Attempts, queued.NextAttemptAt)
+			}
+			if _, ok := original["deferred@example.com"]; ok {
+				t.Errorf("expected the deferred recipient's entry to move to the retry, got %v", original)
+			}
+			if status := deliveryLog.entries[queued.ID]["deferred@example.com"]; status != tt.wantLogStatus {
+				t.Errorf("expected the moved entry to be %q, got %q", tt.wantLogStatus, status)
+			}
+
+			// Delivering the retry goes to the deferred recipient alone
+			mailer.refused = nil
+			queued.Attempts++
+			if err := dispatcher.deliver(queued); err != nil {
+				t.Fatal(err)
+			}
+			if last := mailer.sent[len(mailer.sent)-1]; strings.Join(last, ",") != "deferred@example.com" {
+				t.Errorf("expected the retry to reach only the deferred recipient, got %v", last)
+			}
+			if status := deliveryLog.entries[queued.ID]["deferred@example.com"]; status != domain.DeliveryStatusSent {
+				t.Errorf("expected the deferred recipient to be sent on retry, got %q", status)
+			}
+		})
+	}
+}
@@ -1,43 +1,373 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
 	"monolith-domain/internal/mailer/domain"
+	"monolith-domain/internal/sharedkernel"
+	"monolith-domain/pkg/observability"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
 // MailerService defines the email sending operations.
+// Single mails are written to the outbox and delivered asynchronously by the MailDispatcher;
+// bulk sends are persisted the same way but delivered inline so the caller gets a report.
+// Recipient addresses are validated and normalised before anything is stored.
 type MailerService struct {
-	repo domain.MailerRepository
+	outbox           domain.OutboxRepository
+	deliveryLog      domain.DeliveryLogRepository
+	suppressions     domain.SuppressionRepository
+	dispatcher       *MailDispatcher
+	bulkConcurrency  int
+	attachmentLimits domain.AttachmentLimits
+	addresses        *sharedkernel.AddressValidator
+	logger           *zap.Logger
 }
 
-func NewMailerService(repo domain.MailerRepository) *MailerService {
+func NewMailerService(outbox domain.OutboxRepository, deliveryLog domain.DeliveryLogRepository, suppressions domain.SuppressionRepository, dispatcher *MailDispatcher, bulkConcurrency int, attachmentLimits domain.AttachmentLimits, addresses *sharedkernel.AddressValidator) *MailerService {
+	if bulkConcurrency < 1 {
+		bulkConcurrency = 1
+	}
 	return &MailerService{
-		repo: repo,
+		outbox:           outbox,
+		deliveryLog:      deliveryLog,
+		suppressions:     suppressions,
+		dispatcher:       dispatcher,
+		bulkConcurrency:  bulkConcurrency,
+		attachmentLimits: attachmentLimits,
+		addresses:        addresses,
+		logger:           observability.GetLogger(),
+	}
+}
+
+// SendMail queues a single email and returns its message ID. It is refused with
+// ErrInvalidEmail or ErrDisposableEmail for a malformed or disposable address and with
+// ErrRecipientSuppressed if any recipient is on the suppression list.
+func (s *MailerService) SendMail(mail domain.Mail) (uuid.UUID, error) {
+	return s.queueMail(mail, time.Time{})
+}
+
+// ScheduleMail stores a single email that the dispatcher sends once sendAt is reached.
+// Until then it can be rescheduled or cancelled.
+func (s *MailerService) ScheduleMail(mail domain.Mail, sendAt time.Time) (uuid.UUID, error) {
+	if !sendAt.After(time.Now()) {
+		return uuid.Nil, domain.ErrInvalidSchedule
+	}
+	return s.queueMail(mail, sendAt)
+}
+
+// queueMail validates mail and writes it to the outbox, due at once when sendAt is zero.
+func (s *MailerService) queueMail(mail domain.Mail, sendAt time.Time) (uuid.UUID, error) {
+	if err := mail.Validate(); err != nil {
+		return uuid.Nil, err
+	}
+	mail, err := s.normalizeAddresses(mail)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	suppressed, err := suppressedAddresses(s.suppressions, mail.Recipients())
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if len(suppressed) > 0 {
+		return uuid.Nil, fmt.Errorf("%w: %s", domain.ErrRecipientSuppressed, strings.Join(sortedAddresses(suppressed), ", "))
+	}
+	if err := domain.ValidateAttachments(mail.Attachments, s.attachmentLimits); err != nil {
+		return uuid.Nil, err
+	}
+
+	mail = mail.WithTextAlternative()
+	message, err := domain.NewOutboxMessage(mail)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if !sendAt.IsZero() {
+		message.Schedule(sendAt)
+	}
+
+	if err := s.enqueue([]*domain.OutboxMessage{message}, []domain.Mail{mail}); err != nil {
+		return uuid.Nil, err
+	}
+	if sendAt.IsZero() {
+		s.dispatcher.Notify()
+	}
+
+	return message.ID, nil
+}
+
+// SendBulkEmails sends one email per recipient with at most bulkConcurrency deliveries in
+// flight and returns a result for every recipient, in input order. Invalid, disposable,
+// duplicate and suppressed addresses are skipped. All other messages are stored in the outbox already claimed,
+// so a transient failure is retried by the dispatcher and a crash mid-send is recovered
+// once the lock timeout expires. Messages held back by the send rate limits are left
+// in the outbox for the dispatcher and reported as queued, as are messages whose claim
+// expired during a long run and that the dispatcher took over.
+// The recipient-independent content is taken from mail; its To field is ignored.
+func (s *MailerService) SendBulkEmails(recipients []string, mail domain.Mail) ([]domain.RecipientResult, error) {
+	if err := domain.ValidateHeaders(mail); err != nil {
+		return nil, err
 	}
+
+	mails := make([]domain.Mail, len(recipients))
+	for i, recipient := range recipients {
+		mails[i] = mail
+		mails[i].To = []string{recipient}
+	}
+	return s.SendBulkMails(mails)
 }
 
-// SendMail sends a single email.
-func (s *MailerService) SendMail(to string, subject string, body string, isHTML bool) error {
-	mail := domain.Mail{
-		To:      to,
-		Subject: subject,
-		Body:    body,
-		IsHTML:  isHTML,
+// SendBulkMails is SendBulkEmails for mails personalised per recipient: every mail is
+// addressed to its single To recipient. A mail with invalid headers skips its recipient.
+func (s *MailerService) SendBulkMails(bulk []domain.Mail) ([]domain.RecipientResult, error) {
+	results := make([]domain.RecipientResult, len(bulk))
+	messages := make([]*domain.OutboxMessage, 0, len(bulk))
+	positions := make([]int, 0, len(bulk))
+	recipients := make([]string, len(bulk))
+	seen := make(map[string]bool, len(bulk))
+	now := time.Now()
+	for i, mail := range bulk {
+		if len(mail.To) != 1 {
+			return nil, fmt.Errorf("%w: bulk mail %d has %d recipients", domain.ErrNoRecipients, i, len(mail.To))
+		}
+		recipients[i] = mail.To[0]
+	}
+	suppressed, err := suppressedAddresses(s.suppressions, recipients)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate all addresses up front so the domains are resolved concurrently
+	addresses, addressErrs := s.addresses.ParseAll(recipients)
+
+	mails := make([]domain.Mail, 0, len(recipients))
+	for i, email := range recipients {
+		results[i].Recipient = email
+		mail := bulk[i].WithTextAlternative()
+		if err := domain.ValidateHeaders(mail); err != nil {
+			results[i].Status = domain.RecipientStatusSkipped
+			results[i].Reason = err.Error()
+			continue
+		}
+		address, err := addresses[i], addressErrs[i]
+		if err != nil {
+			results[i].Status = domain.RecipientStatusSkipped
+			results[i].Reason = err.Error()
+			var domainErr *sharedkernel.DomainError
+			if errors.As(err, &domainErr) {
+				results[i].Suggestion = domainErr.Suggestion
+			}
+			continue
+		}
+		if seen[address.Email] {
+			results[i].Status = domain.RecipientStatusSkipped
+			results[i].Reason = "duplicate recipient"
+			continue
+		}
+		seen[address.Email] = true
+		if reason, ok := suppressed[address.Email]; ok {
+			results[i].Status = domain.RecipientStatusSkipped
+			results[i].Reason = fmt.Sprintf("suppressed (%s)", reason)
+			continue
+		}
+
+		mail.To = []string{address.String()}
+		message, err := domain.NewOutboxMessage(mail)
+		if err != nil {
+			return nil, err
+		}
+		message.Claim(now)
+		messages = append(messages, message)
+		mails = append(mails, mail)
+		positions = append(positions, i)
 	}
-	return s.repo.Send(mail)
+
+	if err := s.enqueue(messages, mails); err != nil {
+		return nil, err
+	}
+
+	sem := make(chan struct{}, s.bulkConcurrency)
+	var wg sync.WaitGroup
+	for n, message := range messages {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(result *domain.RecipientResult, message *domain.OutboxMessage) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			id := message.ID
+			result.MessageID = &id
+			// The claim was taken before the run started and may have expired while the
+			// message waited for its turn; renew it so the dispatcher cannot send it too
+			if err := s.outbox.Relock(message); err != nil {
+				result.Status = domain.RecipientStatusQueued
+				result.Reason = "left to the dispatcher: " + err.Error()
+				return
+			}
+			err := s.dispatcher.deliver(message)
+			if errors.Is(err, errDeliveryDeferred) {
+				result.Status = domain.RecipientStatusQueued
+				result.Reason = err.Error()
+				return
+			}
+			if err != nil {
+				result.Status = domain.RecipientStatusFailed
+				result.Reason = err.Error()
+				return
+			}
+			result.Status = domain.RecipientStatusSent
+		}(&results[positions[n]], message)
+	}
+	wg.Wait()
+
+	return results, nil
 }
 
-// SendBulkEmails sends emails to multiple recipients.
-func (s *MailerService) SendBulkEmails(recipients []string, subject, body string, isHTML bool) error {
-	for _, email := range recipients {
-		mail := domain.Mail{
-			To:      email,
-			Subject: subject,
-			Body:    body,
-			IsHTML:  isHTML,
+// normalizeAddresses validates the recipient and Reply-To addresses of mail and returns
+// it with every address in normalised form. Only recipients are checked against the
+// disposable domains.
+func (s *MailerService) normalizeAddresses(mail domain.Mail) (domain.Mail, error) {
+	for _, list := range []*[]string{&mail.To, &mail.Cc, &mail.Bcc} {
+		if len(*list) == 0 {
+			continue
 		}
-		if err := s.repo.Send(mail); err != nil {
-			return err
+		normalized := make([]string, len(*list))
+		for i, raw := range *list {
+			address, err := s.addresses.Parse(raw)
+			if err != nil {
+				return mail, err
+			}
+			normalized[i] = address.String()
 		}
+		*list = normalized
+	}
+
+	if mail.ReplyTo != "" {
+		address, err := sharedkernel.ParseAddress(mail.ReplyTo)
+		if err != nil {
+			return mail, err
+		}
+		mail.ReplyTo = address.String()
+	}
+	return mail, nil
+}
+
+// suppressedAddresses returns the reasons of those addresses that are on the suppression
+// list, keyed by normalised address.
+func suppressedAddresses(suppressions domain.SuppressionRepository, addresses []string) (map[string]domain.SuppressionReason, error) {
+	emails := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		emails = append(emails, domain.NormalizeRecipient(address))
+	}
+
+	found, err := suppressions.FindByEmails(emails)
+	if err != nil {
+		return nil, err
+	}
+
+	reasons := make(map[string]domain.SuppressionReason, len(found))
+	for _, suppression := range found {
+		reasons[suppression.Email] = suppression.Reason
+	}
+	return reasons, nil
+}
+
+// sortedAddresses returns the addresses of suppressed in order, for stable messages.
+func sortedAddresses(suppressed map[string]domain.SuppressionReason) []string {
+	addresses := make([]string, 0, len(suppressed))
+	for address := range suppressed {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// enqueue writes the delivery log entries and then the outbox rows. The log comes
+// first so the dispatcher never delivers a message whose entries do not exist yet.
+func (s *MailerService) enqueue(messages []*domain.OutboxMessage, mails []domain.Mail) error {
+	var entries []*domain.DeliveryLogEntry
+	for i, message := range messages {
+		entries = append(entries, domain.NewDeliveryLogEntries(message, mails[i])...)
+	}
+	if err := s.deliveryLog.Record(entries...); err != nil {
+		return err
+	}
+
+	if err := s.outbox.Enqueue(messages...); err != nil {
+		for _, message := range messages {
+			s.updateDeliveryLog(message.ID, domain.DeliveryUpdate{
+				Status:   domain.DeliveryStatusFailed,
+				Response: "failed to queue message: " + err.Error(),
+			})
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *MailerService) updateDeliveryLog(id uuid.UUID, update domain.DeliveryUpdate) {
+	if err := s.deliveryLog.Update(id, update); err != nil {
+		s.logger.Error("Failed to update delivery log", zap.String("id", id.String()), zap.Error(err))
+	}
+}
+
+// SearchDeliveries returns delivery log entries matching filter, newest first.
+func (s *MailerService) SearchDeliveries(filter domain.DeliveryLogFilter) ([]*domain.DeliveryLogEntry, int64, error) {
+	filter.Recipient = domain.NormalizeRecipient(filter.Recipient)
+	return s.deliveryLog.Search(filter)
+}
+
+// GetMessage returns the queued message with its current delivery status.
+func (s *MailerService) GetMessage(id uuid.UUID) (*domain.OutboxMessage, error) {
+	return s.outbox.FindByID(id)
+}
+
+// GetDeadLetters returns dead-lettered messages with pagination.
+func (s *MailerService) GetDeadLetters(page, size int) ([]*domain.OutboxMessage, int64, error) {
+	return s.outbox.FindAllDead(page, size)
+}
+
+// RequeueMessage gives a dead-lettered message a fresh set of delivery attempts.
+func (s *MailerService) RequeueMessage(id uuid.UUID) error {
+	if err := s.outbox.Requeue(id); err != nil {
+		return err
+	}
+	s.updateDeliveryLog(id, domain.DeliveryUpdate{Status: domain.DeliveryStatusQueued, Response: "requeued"})
+	s.dispatcher.Notify()
+	return nil
+}
+
+// GetScheduledMessages returns the messages waiting for their send time, soonest first.
+func (s *MailerService) GetScheduledMessages(page, size int) ([]*domain.OutboxMessage, int64, error) {
+	return s.outbox.FindAllScheduled(page, size)
+}
+
+// RescheduleMessage moves the send time of a message that has not gone out yet.
+func (s *MailerService) RescheduleMessage(id uuid.UUID, sendAt time.Time) error {
+	if !sendAt.After(time.Now()) {
+		return domain.ErrInvalidSchedule
+	}
+	if err := s.outbox.Reschedule(id, sendAt); err != nil {
+		return err
+	}
+	s.updateDeliveryLog(id, domain.DeliveryUpdate{
+		Status:   domain.DeliveryStatusScheduled,
+		Response: "rescheduled for " + sendAt.Format(time.RFC3339),
+	})
+	return nil
+}
+
+// CancelMessage stops a scheduled message from being sent.
+func (s *MailerService) CancelMessage(id uuid.UUID) error {
+	if err := s.outbox.Cancel(id); err != nil {
+		return err
 	}
+	s.updateDeliveryLog(id, domain.DeliveryUpdate{Status: domain.DeliveryStatusCancelled, Response: "cancelled"})
 	return nil
 }
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"monolith-domain/internal/mailer/domain"
+
+	"github.com/google/uuid"
+)
+
+// htmlClipSize is the HTML size above which Gmail truncates a message behind a
+// "View entire message" link, hiding everything below it including the tracking pixel.
+const htmlClipSize = 102 << 10
+
+// PreviewService builds mails, and mails rendered from templates, as the transports
+// would but without DKIM signature and tracking, and without queueing or sending them. Problems that would not stop
+// the mail from being built are reported as warnings.
+type PreviewService struct {
+	renderer       domain.MessageRenderer
+	mailerService  *MailerService
+	templates      *TemplateService
+	maxMessageSize int64
+}
+
+// NewPreviewService creates a preview service. maxMessageSize is the raw message size
+// above which a warning is given; zero disables the check.
+func NewPreviewService(renderer domain.MessageRenderer, mailerService *MailerService, templates *TemplateService, maxMessageSize int64) *PreviewService {
+	return &PreviewService{
+		renderer:       renderer,
+		mailerService:  mailerService,
+		templates:      templates,
+		maxMessageSize: maxMessageSize,
+	}
+}
+
+// PreviewMail builds mail as SendMail would queue it.
+func (s *PreviewService) PreviewMail(mail domain.Mail) (*domain.MailPreview, error) {
+	return s.preview(mail, []string{})
+}
+
+// PreviewTemplate renders the named template for to and builds the resulting mail.
+// Variables missing from data are reported rather than failing the render.
+func (s *PreviewService) PreviewTemplate(to, name, lang string, data map[string]interface{}) (*domain.MailPreview, error) {
+	rendered, warnings, err := s.templates.PreviewTemplate(name, lang, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.preview(domain.Mail{
+		To:       []string{to},
+		Subject:  rendered.Subject,
+		HTMLBody: rendered.HTMLBody,
+		TextBody: rendered.TextBody,
+		Template: name,
+	}, warnings)
+}
+
+func (s *PreviewService) preview(mail domain.Mail, warnings []string) (*domain.MailPreview, error) {
+	if err := mail.Validate(); err != nil {
+		return nil, err
+	}
+	mail, err := s.mailerService.normalizeAddresses(mail)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.ValidateAttachments(mail.Attachments, s.mailerService.attachmentLimits); err != nil {
+		if !errors.Is(err, domain.ErrAttachmentTooLarge) {
+			return nil, err
+		}
+		warnings = append(warnings, err.Error()+"; the email would be refused")
+	}
+
+	suppressed, err := suppressedAddresses(s.mailerService.suppressions, mail.Recipients())
+	if err != nil {
+		return nil, err
+	}
+	for _, address := range sortedAddresses(suppressed) {
+		warnings = append(warnings, fmt.Sprintf("%s is suppressed (%s); the email would be refused", address, suppressed[address]))
+	}
+
+	// A fresh ID stands in for the outbox message ID, so the Message-ID looks the way
+	// it will when sent
+	mail = mail.WithTextAlternative()
+	mail.ID = uuid.New()
+	raw, err := s.renderer.Render(mail)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.maxMessageSize > 0 && int64(len(raw)) > s.maxMessageSize {
+		warnings = append(warnings, fmt.Sprintf("message is %d bytes, larger than the %d bytes receiving servers are expected to accept", len(raw), s.maxMessageSize))
+	}
+	if len(mail.HTMLBody) > htmlClipSize {
+		warnings = append(warnings, fmt.Sprintf("HTML body is %d bytes; Gmail clips messages with more than %d bytes of HTML", len(mail.HTMLBody), htmlClipSize))
+	}
+
+	return &domain.MailPreview{
+		Subject:  mail.Subject,
+		HTMLBody: mail.HTMLBody,
+		TextBody: mail.TextBody,
+		Raw:      string(raw),
+		Size:     len(raw),
+		Warnings: warnings,
+	}, nil
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"time"
+
+	"monolith-domain/internal/mailer/domain"
+	"monolith-domain/pkg/observability"
+
+	"golang.org/x/time/rate"
+)
+
+// globalRateLimiter is the metrics label of the limit that applies to all mail.
+const globalRateLimiter = "global"
+
+// SendRateLimiter enforces the outgoing send rates: one limit for all mail and one per
+// recipient domain. Every recipient takes a token from the global limiter and from
+// the limiter of its domain.
+type SendRateLimiter struct {
+	global  *rate.Limiter
+	domains map[string]*rate.Limiter
+}
+
+// NewSendRateLimiter creates a limiter. It returns nil, meaning unlimited, when none of
+// the limits is enabled; a nil *SendRateLimiter is safe to use.
+func NewSendRateLimiter(global domain.RateLimit, domains map[string]domain.RateLimit) *SendRateLimiter {
+	limiter := &SendRateLimiter{domains: make(map[string]*rate.Limiter)}
+	if global.Enabled() {
+		limiter.global = rate.NewLimiter(rate.Limit(global.PerSecond), global.BurstSize())
+	}
+	for name, limit := range domains {
+		if limit.Enabled() {
+			limiter.domains[name] = rate.NewLimiter(rate.Limit(limit.PerSecond), limit.BurstSize())
+		}
+	}
+	if limiter.global == nil && len(limiter.domains) == 0 {
+		return nil
+	}
+	return limiter
+}
+
+// SendReservation holds the tokens taken for one message.
+type SendReservation struct {
+	reservations []*rate.Reservation
+	delay        time.Duration
+	limiter      string // label of the limiter that imposes the delay
+}
+
+// Cancel returns the tokens, for a message that is not sent now after all.
+func (r *SendReservation) Cancel() {
+	if r == nil {
+		return
+	}
+	for _, reservation := range r.reservations {
+		reservation.Cancel()
+	}
+}
+
+// Delay is how long the message has to wait before it may be sent.
+func (r *SendReservation) Delay() time.Duration {
+	if r == nil {
+		return 0
+	}
+	return r.delay
+}
+
+// Limiter names the limit causing the delay: "global" or a recipient domain.
+func (r *SendReservation) Limiter() string {
+	if r == nil {
+		return ""
+	}
+	return r.limiter
+}
+
+// Reserve takes the tokens needed to send to recipients. The message may be sent once
+// the reservation's delay has passed, or the reservation must be cancelled.
+func (l *SendRateLimiter) Reserve(recipients []string) *SendReservation {
+	if l == nil {
+		return nil
+	}
+
+	now := time.Now()
+	reservation := &SendReservation{}
+	take := func(name string, limiter *rate.Limiter, n int) {
+		// A message needing more than the burst could never be sent, so it is capped
+		if n > limiter.Burst() {
+			n = limiter.Burst()
+		}
+		r := limiter.ReserveN(now, n)
+		reservation.reservations = append(reservation.reservations, r)
+		if delay := r.DelayFrom(now); delay > reservation.delay {
+			reservation.delay = delay
+			reservation.limiter = name
+		}
+		observability.SetRateLimitTokens(name, limiter.TokensAt(now))
+	}
+
+	if l.global != nil {
+		take(globalRateLimiter, l.global, len(recipients))
+	}
+	perDomain := make(map[string]int)
+	for _, recipient := range recipients {
+		if name := domain.RecipientDomain(recipient); l.domains[name] != nil {
+			perDomain[name]++
+		}
+	}
+	for name, n := range perDomain {
+		take(name, l.domains[name], n)
+	}
+	return reservation
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"monolith-domain/internal/mailer/domain"
+
+	"github.com/google/uuid"
+)
+
+// SuppressionService manages the suppression list. Entries are also added by the
+// MailDispatcher when a server permanently rejects a recipient, and by the
+// BounceService for hard bounces reported after delivery.
+type SuppressionService struct {
+	repo domain.SuppressionRepository
+}
+
+func NewSuppressionService(repo domain.SuppressionRepository) *SuppressionService {
+	return &SuppressionService{repo: repo}
+}
+
+func (s *SuppressionService) CreateSuppression(email string, reason domain.SuppressionReason, detail string) (*domain.Suppression, error) {
+	suppression, err := domain.NewSuppression(email, reason, detail)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Create(suppression); err != nil {
+		return nil, err
+	}
+	return suppression, nil
+}
+
+func (s *SuppressionService) UpdateSuppression(id uuid.UUID, reason domain.SuppressionReason, detail string) (*domain.Suppression, error) {
+	if !reason.Valid() {
+		return nil, domain.ErrInvalidSuppressionReason
+	}
+
+	suppression, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	suppression.Reason = reason
+	suppression.Detail = detail
+	if err := s.repo.Update(suppression); err != nil {
+		return nil, err
+	}
+	return suppression, nil
+}
+
+func (s *SuppressionService) DeleteSuppression(id uuid.UUID) error {
+	return s.repo.Delete(id)
+}
+
+func (s *SuppressionService) GetSuppressionByID(id uuid.UUID) (*domain.Suppression, error) {
+	return s.repo.FindByID(id)
+}
+
+// GetSuppressionByEmail looks an address up in the list.
+func (s *SuppressionService) GetSuppressionByEmail(email string) (*domain.Suppression, error) {
+	suppressions, err := s.repo.FindByEmails([]string{domain.NormalizeRecipient(email)})
+	if err != nil {
+		return nil, err
+	}
+	if len(suppressions) == 0 {
+		return nil, domain.ErrSuppressionNotFound
+	}
+	return suppressions[0], nil
+}
+
+// GetAllSuppressions lists entries, optionally only those with reason.
+func (s *SuppressionService) GetAllSuppressions(reason domain.SuppressionReason, page, size int) ([]*domain.Suppression, int64, error) {
+	if reason != "" && !reason.Valid() {
+		return nil, 0, domain.ErrInvalidSuppressionReason
+	}
+	return s.repo.FindAll(reason, page, size)
+}
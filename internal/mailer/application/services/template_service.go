@@ -0,0 +1,303 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"monolith-domain/internal/mailer/domain"
+
+	"github.com/google/uuid"
+)
+
+// TemplateService manages email templates and sends mail rendered from them.
+// Templates can pull localized strings with {{t "key"}}; the language is chosen per render.
+type TemplateService struct {
+	repo          domain.TemplateRepository
+	translator    domain.TranslationProvider
+	mailerService *MailerService
+}
+
+func NewTemplateService(repo domain.TemplateRepository, translator domain.TranslationProvider, mailerService *MailerService) *TemplateService {
+	return &TemplateService{
+		repo:          repo,
+		translator:    translator,
+		mailerService: mailerService,
+	}
+}
+
+func (s *TemplateService) CreateTemplate(name, subject, htmlBody, textBody string) (*domain.EmailTemplate, error) {
+	existing, err := s.repo.FindByName(name)
+	if err == nil && existing != nil {
+		return nil, domain.ErrTemplateExists
+	}
+
+	template := &domain.EmailTemplate{
+		Name:     name,
+		Subject:  subject,
+		HTMLBody: htmlBody,
+		TextBody: textBody,
+	}
+	if err := validateTemplate(template); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(template); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func (s *TemplateService) UpdateTemplate(id uuid.UUID, subject, htmlBody, textBody string) (*domain.EmailTemplate, error) {
+	template, err := s.repo.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	template.Subject = subject
+	template.HTMLBody = htmlBody
+	template.TextBody = textBody
+	if err := validateTemplate(template); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(template); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+func (s *TemplateService) DeleteTemplate(id uuid.UUID) error {
+	if _, err := s.repo.FindByID(id); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+func (s *TemplateService) GetTemplateByID(id uuid.UUID) (*domain.EmailTemplate, error) {
+	return s.repo.FindByID(id)
+}
+
+func (s *TemplateService) GetAllTemplates(page, size int) ([]*domain.EmailTemplate, int64, error) {
+	return s.repo.FindAll(page, size)
+}
+
+// RenderTemplate renders the named template with data, resolving translations in lang.
+func (s *TemplateService) RenderTemplate(name, lang string, data map[string]interface{}) (*domain.RenderedTemplate, error) {
+	template, err := s.repo.FindByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return renderTemplate(template, data, s.templateFuncs(lang))
+}
+
+// PreviewTemplate renders the named template like RenderTemplate, but variables missing
+// from data are rendered empty and reported as warnings instead of failing the render.
+func (s *TemplateService) PreviewTemplate(name, lang string, data map[string]interface{}) (*domain.RenderedTemplate, []string, error) {
+	template, err := s.repo.FindByName(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	missing, err := missingVariables(template, data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", domain.ErrInvalidTemplate, err)
+	}
+	warnings := make([]string, 0, len(missing))
+	for _, path := range missing {
+		warnings = append(warnings, fmt.Sprintf("template variable .%s is missing from data", strings.Join(path, ".")))
+	}
+
+	rendered, err := renderTemplate(template, withPlaceholders(data, missing), s.templateFuncs(lang))
+	if err != nil {
+		return nil, nil, err
+	}
+	return rendered, warnings, nil
+}
+
+// SendTemplate renders the named template in lang and queues the result for to.
+func (s *TemplateService) SendTemplate(to, name, lang string, data map[string]interface{}) (uuid.UUID, error) {
+	rendered, err := s.RenderTemplate(name, lang, data)
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	return s.mailerService.SendMail(domain.Mail{
+		To:       []string{to},
+		Subject:  rendered.Subject,
+		HTMLBody: rendered.HTMLBody,
+		TextBody: rendered.TextBody,
+		Template: name,
+	})
+}
+
+// SendBulkTemplate sends the named template to every recipient, rendered with the
+// recipient's variables. The To field of mail is ignored.
+func (s *TemplateService) SendBulkTemplate(recipients []domain.BulkRecipient, name, lang string, mail domain.Mail) ([]domain.RecipientResult, error) {
+	template, err := s.repo.FindByName(name)
+	if err != nil {
+		return nil, err
+	}
+	mail.Template = name
+	return s.sendMerged(template, recipients, lang, mail)
+}
+
+// SendBulkMerge sends mail to every recipient with its subject and bodies rendered as a
+// template with the recipient's variables.
+func (s *TemplateService) SendBulkMerge(recipients []domain.BulkRecipient, lang string, mail domain.Mail) ([]domain.RecipientResult, error) {
+	template := &domain.EmailTemplate{
+		Subject:  mail.Subject,
+		HTMLBody: mail.HTMLBody,
+		TextBody: mail.TextBody,
+	}
+	if err := validateTemplateContent(template); err != nil {
+		return nil, err
+	}
+	return s.sendMerged(template, recipients, lang, mail)
+}
+
+// sendMerged renders template for every recipient, in the recipient's language or else
+// lang, and hands the results to one bulk send. Nothing is sent unless every recipient
+// provides every variable the template uses; a *domain.MissingVariablesError lists
+// what is missing.
+func (s *TemplateService) sendMerged(template *domain.EmailTemplate, recipients []domain.BulkRecipient, lang string, mail domain.Mail) ([]domain.RecipientResult, error) {
+	refs, err := templateVariables(template)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidTemplate, err)
+	}
+
+	variables := make([]map[string]interface{}, len(recipients))
+	missing := make(map[string][]string)
+	for i, recipient := range recipients {
+		variables[i] = recipient.Variables()
+		for _, path := range missingFrom(refs, variables[i]) {
+			missing[recipient.Email] = append(missing[recipient.Email], "."+strings.Join(path, "."))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, &domain.MissingVariablesError{Missing: missing}
+	}
+
+	mails := make([]domain.Mail, len(recipients))
+	for i, recipient := range recipients {
+		recipientLang := recipient.Lang
+		if recipientLang == "" {
+			recipientLang = lang
+		}
+		rendered, err := renderTemplate(template, variables[i], s.templateFuncs(recipientLang))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", recipient.Email, err)
+		}
+
+		mails[i] = mail
+		mails[i].To = []string{recipient.Email}
+		mails[i].Subject = rendered.Subject
+		mails[i].HTMLBody = rendered.HTMLBody
+		mails[i].TextBody = rendered.TextBody
+	}
+	return s.mailerService.SendBulkMails(mails)
+}
+
+// templateFuncs exposes translations to templates:
+//
+//	{{t "welcome.title"}}  translated string for the render language
+//	{{lang}}               the render language itself
+//
+// Lookups are memoized for the duration of one render.
+func (s *TemplateService) templateFuncs(lang string) texttemplate.FuncMap {
+	cache := make(map[string]string)
+	return texttemplate.FuncMap{
+		"t": func(key string) (string, error) {
+			if value, ok := cache[key]; ok {
+				return value, nil
+			}
+			value, err := s.translator.Translate(key, lang)
+			if err != nil {
+				return "", err
+			}
+			cache[key] = value
+			return value, nil
+		},
+		"lang": func() string { return lang },
+	}
+}
+
+// parseFuncs stands in for templateFuncs when templates are only parsed.
+var parseFuncs = texttemplate.FuncMap{
+	"t":    func(string) (string, error) { return "", nil },
+	"lang": func() string { return "" },
+}
+
+// validateTemplate checks required fields and that every part parses.
+func validateTemplate(template *domain.EmailTemplate) error {
+	if template.Name == "" || template.Subject == "" {
+		return fmt.Errorf("%w: name and subject are required", domain.ErrInvalidTemplate)
+	}
+	return validateTemplateContent(template)
+}
+
+// validateTemplateContent checks the parts of a template, named or not.
+func validateTemplateContent(template *domain.EmailTemplate) error {
+	if template.Subject == "" {
+		return fmt.Errorf("%w: subject is required", domain.ErrInvalidTemplate)
+	}
+	if template.HTMLBody == "" && template.TextBody == "" {
+		return fmt.Errorf("%w: html_body or text_body is required", domain.ErrInvalidTemplate)
+	}
+
+	if _, err := texttemplate.New("subject").Funcs(parseFuncs).Parse(template.Subject); err != nil {
+		return fmt.Errorf("%w: subject: %v", domain.ErrInvalidTemplate, err)
+	}
+	if _, err := htmltemplate.New("html").Funcs(htmltemplate.FuncMap(parseFuncs)).Parse(template.HTMLBody); err != nil {
+		return fmt.Errorf("%w: html_body: %v", domain.ErrInvalidTemplate, err)
+	}
+	if _, err := texttemplate.New("text").Funcs(parseFuncs).Parse(template.TextBody); err != nil {
+		return fmt.Errorf("%w: text_body: %v", domain.ErrInvalidTemplate, err)
+	}
+	return nil
+}
+
+// renderTemplate executes every part of the template. Referencing a key that is
+// not in data is an error rather than a silent "<no value>".
+func renderTemplate(template *domain.EmailTemplate, data map[string]interface{}, funcs texttemplate.FuncMap) (*domain.RenderedTemplate, error) {
+	rendered := &domain.RenderedTemplate{}
+	var err error
+
+	if rendered.Subject, err = executeText("subject", template.Subject, data, funcs); err != nil {
+		return nil, err
+	}
+	if template.HTMLBody != "" {
+		tmpl, err := htmltemplate.New("html").Option("missingkey=error").Funcs(htmltemplate.FuncMap(funcs)).Parse(template.HTMLBody)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrInvalidTemplate, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrTemplateRender, err)
+		}
+		rendered.HTMLBody = buf.String()
+	}
+	if template.TextBody != "" {
+		if rendered.TextBody, err = executeText("text", template.TextBody, data, funcs); err != nil {
+			return nil, err
+		}
+	}
+
+	return rendered, nil
+}
+
+func executeText(name, text string, data map[string]interface{}, funcs texttemplate.FuncMap) (string, error) {
+	tmpl, err := texttemplate.New(name).Option("missingkey=error").Funcs(funcs).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", domain.ErrInvalidTemplate, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%w: %v", domain.ErrTemplateRender, err)
+	}
+	return buf.String(), nil
+}
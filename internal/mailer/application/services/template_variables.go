@@ -0,0 +1,161 @@
+package services
+
+import (
+	"strings"
+	texttemplate "text/template"
+	"text/template/parse"
+
+	"monolith-domain/internal/mailer/domain"
+)
+
+// missingVariables returns the variables (".user.name") referenced by the template that
+// data does not provide, in order of first use.
+func missingVariables(template *domain.EmailTemplate, data map[string]interface{}) ([][]string, error) {
+	refs, err := templateVariables(template)
+	if err != nil {
+		return nil, err
+	}
+	return missingFrom(refs, data), nil
+}
+
+// templateVariables returns the distinct variables referenced by the template, in order
+// of first use. Only references relative to the root data are collected; inside range
+// and with blocks just $-rooted ones are.
+func templateVariables(template *domain.EmailTemplate) ([][]string, error) {
+	var refs [][]string
+	for _, part := range []string{template.Subject, template.HTMLBody, template.TextBody} {
+		// html/template shares the text/template syntax, so one parser serves every part
+		tmpl, err := texttemplate.New("part").Funcs(parseFuncs).Parse(part)
+		if err != nil {
+			return nil, err
+		}
+		if tmpl.Tree != nil {
+			collectVariables(tmpl.Tree.Root, true, &refs)
+		}
+	}
+
+	seen := make(map[string]bool)
+	distinct := refs[:0]
+	for _, ref := range refs {
+		key := strings.Join(ref, ".")
+		if !seen[key] {
+			seen[key] = true
+			distinct = append(distinct, ref)
+		}
+	}
+	return distinct, nil
+}
+
+// missingFrom returns the variables of refs that data does not provide.
+func missingFrom(refs [][]string, data map[string]interface{}) [][]string {
+	var missing [][]string
+	for _, ref := range refs {
+		if !hasVariable(data, ref) {
+			missing = append(missing, ref)
+		}
+	}
+	return missing
+}
+
+// collectVariables appends the field chains used under node. dotIsRoot is false where
+// range or with have rebound the dot.
+func collectVariables(node parse.Node, dotIsRoot bool, refs *[][]string) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectVariables(child, dotIsRoot, refs)
+		}
+	case *parse.ActionNode:
+		collectPipe(n.Pipe, dotIsRoot, refs)
+	case *parse.TemplateNode:
+		collectPipe(n.Pipe, dotIsRoot, refs)
+	case *parse.IfNode:
+		collectBranch(&n.BranchNode, dotIsRoot, dotIsRoot, refs)
+	case *parse.RangeNode:
+		collectBranch(&n.BranchNode, dotIsRoot, false, refs)
+	case *parse.WithNode:
+		collectBranch(&n.BranchNode, dotIsRoot, false, refs)
+	}
+}
+
+func collectBranch(n *parse.BranchNode, dotIsRoot, bodyDotIsRoot bool, refs *[][]string) {
+	collectPipe(n.Pipe, dotIsRoot, refs)
+	collectVariables(n.List, bodyDotIsRoot, refs)
+	collectVariables(n.ElseList, dotIsRoot, refs)
+}
+
+func collectPipe(pipe *parse.PipeNode, dotIsRoot bool, refs *[][]string) {
+	if pipe == nil {
+		return
+	}
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			switch a := arg.(type) {
+			case *parse.FieldNode:
+				if dotIsRoot {
+					*refs = append(*refs, a.Ident)
+				}
+			case *parse.VariableNode:
+				if len(a.Ident) > 1 && a.Ident[0] == "$" {
+					*refs = append(*refs, a.Ident[1:])
+				}
+			case *parse.PipeNode:
+				collectPipe(a, dotIsRoot, refs)
+			case *parse.ChainNode:
+				if p, ok := a.Node.(*parse.PipeNode); ok {
+					collectPipe(p, dotIsRoot, refs)
+				}
+			}
+		}
+	}
+}
+
+// hasVariable reports whether data provides path. A nil value cannot be descended into;
+// values other than maps cannot be checked and count as present.
+func hasVariable(data map[string]interface{}, path []string) bool {
+	var current interface{} = data
+	for _, key := range path {
+		if current == nil {
+			return false
+		}
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return true
+		}
+		if current, ok = m[key]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// withPlaceholders returns a copy of data in which every missing path is set, to nil
+// for leaves and to a map for intermediate keys, so rendering can go on. data itself
+// is not modified.
+func withPlaceholders(data map[string]interface{}, missing [][]string) map[string]interface{} {
+	for _, path := range missing {
+		data = setPlaceholder(data, path)
+	}
+	return data
+}
+
+func setPlaceholder(m map[string]interface{}, path []string) map[string]interface{} {
+	out := make(map[string]interface{}, len(m)+1)
+	for key, value := range m {
+		out[key] = value
+	}
+
+	key := path[0]
+	if len(path) == 1 {
+		if _, ok := out[key]; !ok {
+			out[key] = nil
+		}
+		return out
+	}
+	child, _ := out[key].(map[string]interface{})
+	out[key] = setPlaceholder(child, path[1:])
+	return out
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"time"
+
+	"monolith-domain/internal/mailer/domain"
+	"monolith-domain/pkg/observability"
+
+	"go.uber.org/zap"
+)
+
+// TrackingService records opens and clicks of tracked messages and reports engagement.
+// Events are stored individually and counted on the message's delivery log entries.
+type TrackingService struct {
+	signer      *domain.TrackingSigner
+	events      domain.TrackingEventRepository
+	deliveryLog domain.DeliveryLogRepository
+	logger      *zap.Logger
+}
+
+func NewTrackingService(signer *domain.TrackingSigner, events domain.TrackingEventRepository, deliveryLog domain.DeliveryLogRepository) *TrackingService {
+	return &TrackingService{
+		signer:      signer,
+		events:      events,
+		deliveryLog: deliveryLog,
+		logger:      observability.GetLogger(),
+	}
+}
+
+// RecordOpen records a load of the tracking pixel identified by token.
+func (s *TrackingService) RecordOpen(token, ip, userAgent string) error {
+	messageID, err := s.signer.ParseOpenToken(token)
+	if err != nil {
+		return err
+	}
+	s.record(&domain.TrackingEvent{
+		MessageID: messageID,
+		Type:      domain.TrackingEventOpen,
+		IP:        ip,
+		UserAgent: userAgent,
+	})
+	return nil
+}
+
+// RecordClick records a click on the tracked link identified by token and returns the
+// URL to redirect to.
+func (s *TrackingService) RecordClick(token, ip, userAgent string) (string, error) {
+	messageID, target, err := s.signer.ParseLinkToken(token)
+	if err != nil {
+		return "", err
+	}
+	s.record(&domain.TrackingEvent{
+		MessageID: messageID,
+		Type:      domain.TrackingEventClick,
+		URL:       target,
+		IP:        ip,
+		UserAgent: userAgent,
+	})
+	return target, nil
+}
+
+// record stores an event. Failures are only logged: the recipient still gets the pixel
+// or the redirect.
+func (s *TrackingService) record(event *domain.TrackingEvent) {
+	event.CreatedAt = time.Now()
+	if err := s.events.Record(event); err != nil {
+		s.logger.Error("Failed to record tracking event", zap.String("id", event.MessageID.String()), zap.Error(err))
+		return
+	}
+	if err := s.deliveryLog.RecordEngagement(event.MessageID, event.Type, event.CreatedAt); err != nil {
+		s.logger.Error("Failed to count tracking event in delivery log", zap.String("id", event.MessageID.String()), zap.Error(err))
+	}
+}
+
+// GetStats returns the opens and clicks of the messages matching filter.
+func (s *TrackingService) GetStats(filter domain.TrackingFilter) (*domain.TrackingStats, error) {
+	return s.events.Stats(filter)
+}
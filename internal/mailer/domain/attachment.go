@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidateAttachments checks that every attachment is named, non-empty, free of header
+// breaking characters and within limits. A zero limit disables that check.
+func ValidateAttachments(attachments []Attachment, limits AttachmentLimits) error {
+	var total int64
+	for _, attachment := range attachments {
+		if attachment.Filename == "" {
+			return fmt.Errorf("%w: filename is required", ErrInvalidAttachment)
+		}
+		if strings.ContainsAny(attachment.Filename+attachment.ContentType+attachment.ContentID, "\r\n") {
+			return fmt.Errorf("%w: %q contains line breaks", ErrInvalidAttachment, attachment.Filename)
+		}
+		if strings.ContainsAny(attachment.ContentID, "<> ") {
+			return fmt.Errorf("%w: content_id %q must not contain '<', '>' or spaces", ErrInvalidAttachment, attachment.ContentID)
+		}
+		if len(attachment.Content) == 0 {
+			return fmt.Errorf("%w: %q is empty", ErrInvalidAttachment, attachment.Filename)
+		}
+
+		size := int64(len(attachment.Content))
+		if limits.MaxSize > 0 && size > limits.MaxSize {
+			return fmt.Errorf("%w: %q is %d bytes, limit is %d", ErrAttachmentTooLarge, attachment.Filename, size, limits.MaxSize)
+		}
+		total += size
+	}
+
+	if limits.MaxTotalSize > 0 && total > limits.MaxTotalSize {
+		return fmt.Errorf("%w: attachments total %d bytes, limit is %d", ErrAttachmentTooLarge, total, limits.MaxTotalSize)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Bounce is one failed or delayed recipient reported by an RFC 3464 delivery status
+// notification.
+type Bounce struct {
+	Recipient         string    `json:"recipient"`
+	Action            string    `json:"action"`                    // failed, delayed, delivered, relayed or expanded
+	Status            string    `json:"status"`                    // RFC 3463 enhanced status code, e.g. "5.1.1"
+	DiagnosticCode    string    `json:"diagnostic_code,omitempty"` // e.g. "550 5.1.1 User unknown"
+	RemoteMTA         string    `json:"remote_mta,omitempty"`
+	OriginalMessageID string    `json:"original_message_id,omitempty"`
+	MessageID         uuid.UUID `json:"message_id"` // Outbox message the bounce belongs to, uuid.Nil when unknown
+}
+
+// Failed reports whether the notification says the message was not delivered.
+func (b Bounce) Failed() bool {
+	return b.Action == "failed"
+}
+
+// Hard reports whether the failure is permanent (status class 5), meaning the
+// address should not be mailed again.
+func (b Bounce) Hard() bool {
+	return b.Failed() && strings.HasPrefix(b.Status, "5.")
+}
+
+// Summary is the text recorded in the delivery log for the bounce.
+func (b Bounce) Summary() string {
+	summary := "bounced " + b.Status
+	if b.DiagnosticCode != "" {
+		summary += ": " + b.DiagnosticCode
+	}
+	return summary
+}
+
+// MessageIDFromHeader returns the outbox message ID encoded in a Message-ID header
+// generated by the mailer ("<id@domain>").
+func MessageIDFromHeader(header string) (uuid.UUID, bool) {
+	header = strings.Trim(strings.TrimSpace(header), "<>")
+	at := strings.LastIndex(header, "@")
+	if at <= 0 {
+		return uuid.Nil, false
+	}
+	id, err := uuid.Parse(header[:at])
+	if err != nil {
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// BounceParser extracts the recipient reports from a raw delivery status notification.
+type BounceParser interface {
+	Parse(raw []byte) ([]Bounce, error)
+}
+
+// NewsletterUnsubscriber removes an address from the newsletter after a hard bounce.
+type NewsletterUnsubscriber interface {
+	UnsubscribeEmail(email string) error
+}
+
+// bounceSignaturePrefix precedes the hex HMAC in a bounce signature header.
+const bounceSignaturePrefix = "sha256="
+
+// BounceSigner signs and verifies notifications posted to the bounce endpoint. The
+// signature is the HMAC-SHA256 of the raw body under a secret shared with the poster,
+// so nobody else can mark recipients bounced or get addresses suppressed.
+type BounceSigner struct {
+	secret []byte
+}
+
+func NewBounceSigner(secret string) *BounceSigner {
+	return &BounceSigner{secret: []byte(secret)}
+}
+
+// Sign returns the signature of body, "sha256=" followed by the hex HMAC.
+func (s *BounceSigner) Sign(body []byte) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(body)
+	return bounceSignaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of body; a nil signer (no secret configured) accepts nothing.
+func (s *BounceSigner) Verify(body []byte, signature string) error {
+	if s == nil || !strings.HasPrefix(signature, bounceSignaturePrefix) {
+		return ErrInvalidBounceSignature
+	}
+	sum, err := hex.DecodeString(strings.TrimPrefix(signature, bounceSignaturePrefix))
+	if err != nil {
+		return ErrInvalidBounceSignature
+	}
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(body)
+	if !hmac.Equal(sum, mac.Sum(nil)) {
+		return ErrInvalidBounceSignature
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBounceSignerVerify(t *testing.T) {
+	body := []byte("Content-Type: multipart/report; report-type=delivery-status\r\n\r\n...")
+	signer := NewBounceSigner("secret")
+	signature := signer.Sign(body)
+
+	tests := []struct {
+		name      string
+		signer    *BounceSigner
+		body      []byte
+		signature string
+		wantValid bool
+	}{
+		{name: "valid signature", signer: signer, body: body, signature: signature, wantValid: true},
+		{name: "missing signature", signer: signer, body: body},
+		{name: "changed body", signer: signer, body: append([]byte("X"), body...), signature: signature},
+		{name: "other secret", signer: NewBounceSigner("other"), body: body, signature: signature},
+		{name: "without prefix", signer: signer, body: body, signature: signature[len("sha256="):]},
+		{name: "not hex", signer: signer, body: body, signature: "sha256=zz"},
+		{name: "no secret configured", body: body, signature: signature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.signer.Verify(tt.body, tt.signature)
+			if tt.wantValid && err != nil {
+				t.Fatalf("expected the signature to verify, got %v", err)
+			}
+			if !tt.wantValid && !errors.Is(err, ErrInvalidBounceSignature) {
+				t.Fatalf("expected ErrInvalidBounceSignature, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// RecipientStatus is the outcome of a bulk send for a single recipient.
+type RecipientStatus string
+
+const (
+	RecipientStatusSent    RecipientStatus = "sent"
+	RecipientStatusFailed  RecipientStatus = "failed"
+	RecipientStatusSkipped RecipientStatus = "skipped"
+	// RecipientStatusQueued means a send rate limit was reached; the message stays in
+	// the outbox and is sent by the dispatcher later.
+	RecipientStatusQueued RecipientStatus = "queued"
+)
+
+// RecipientResult reports what happened to one recipient of a bulk send.
+// MessageID is set for every recipient that made it into the outbox, so failed
+// deliveries that are being retried can still be followed up.
+type RecipientResult struct {
+	Recipient string          `json:"recipient"`
+	Status    RecipientStatus `json:"status"`
+	MessageID *uuid.UUID      `json:"message_id,omitempty"`
+	Reason    string          `json:"reason,omitempty"`
+	// Suggestion is the address probably meant when the recipient domain looks misspelt
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+// BulkRecipient is a recipient of a personalised bulk send with the variables its
+// copy of the message is rendered with. Email and Name are available to the template
+// as .email and .name unless Data sets them; Lang selects translations.
+type BulkRecipient struct {
+	Email string                 `json:"email"`
+	Name  string                 `json:"name,omitempty"`
+	Lang  string                 `json:"lang,omitempty"`
+	Data  map[string]interface{} `json:"data,omitempty"`
+}
+
+// Variables returns the template data of the recipient.
+func (r BulkRecipient) Variables() map[string]interface{} {
+	variables := make(map[string]interface{}, len(r.Data)+2)
+	for key, value := range r.Data {
+		variables[key] = value
+	}
+	if _, ok := variables["email"]; !ok {
+		variables["email"] = r.Email
+	}
+	if _, ok := variables["name"]; !ok && r.Name != "" {
+		variables["name"] = r.Name
+	}
+	return variables
+}
+
+// MissingVariablesError refuses a personalised bulk send in which recipients lack
+// variables the template uses.
+type MissingVariablesError struct {
+	Missing map[string][]string // Missing variables, e.g. ".first_name", by recipient
+}
+
+func (e *MissingVariablesError) Error() string {
+	recipients := make([]string, 0, len(e.Missing))
+	for recipient := range e.Missing {
+		recipients = append(recipients, recipient)
+	}
+	sort.Strings(recipients)
+
+	parts := make([]string, len(recipients))
+	for i, recipient := range recipients {
+		parts[i] = fmt.Sprintf("%s (%s)", recipient, strings.Join(e.Missing[recipient], ", "))
+	}
+	return fmt.Sprintf("%v: %s", ErrMissingMergeVariables, strings.Join(parts, "; "))
+}
+
+func (e *MissingVariablesError) Unwrap() error {
+	return ErrMissingMergeVariables
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+// DeliveryError is returned by MailerRepository implementations when a message could
+// not be handed over. Code carries the SMTP reply code when the server answered, and
+// Recipient the address the server refused when it rejected a single RCPT TO.
+// Permanent errors (5xx replies to the mail transaction) are never retried.
+// RouteFailure marks errors that concern the server rather than this message, such as
+// a refused login or sender, so another relay may still accept the message.
+type DeliveryError struct {
+	Code         int
+	Recipient    string
+	Permanent    bool
+	RouteFailure bool
+	Err          error
+}
+
+func (e *DeliveryError) Error() string {
+	if e.Code != 0 {
+		return fmt.Sprintf("smtp %d: %v", e.Code, e.Err)
+	}
+	return e.Err.Error()
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// IsPermanent reports whether err is a delivery failure that retrying cannot fix.
+func IsPermanent(err error) bool {
+	var deliveryErr *DeliveryError
+	return errors.As(err, &deliveryErr) && deliveryErr.Permanent
+}
+
+// IsMessageRejected reports whether err is a permanent refusal of the message itself,
+// of a recipient or of its content, which every other relay would repeat.
+func IsMessageRejected(err error) bool {
+	var deliveryErr *DeliveryError
+	return errors.As(err, &deliveryErr) && deliveryErr.Permanent && !deliveryErr.RouteFailure
+}
+
+// IsMessageDeferred reports whether err is a transient refusal of the message itself,
+// of a recipient or of its content, e.g. greylisting: the server answered and asked for
+// the message to be tried again later.
+func IsMessageDeferred(err error) bool {
+	var deliveryErr *DeliveryError
+	return errors.As(err, &deliveryErr) && !deliveryErr.Permanent && deliveryErr.Code != 0 && !deliveryErr.RouteFailure
+}
+
+// RejectedRecipient returns the address of a permanent recipient rejection (a 5xx
+// reply to RCPT TO), or false when err is not one.
+func RejectedRecipient(err error) (string, bool) {
+	var deliveryErr *DeliveryError
+	if !errors.As(err, &deliveryErr) || !deliveryErr.Permanent || deliveryErr.Recipient == "" {
+		return "", false
+	}
+	return deliveryErr.Recipient, deliveryErr.Code >= 500 && deliveryErr.Code < 600
+}
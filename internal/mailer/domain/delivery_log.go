@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"net/mail"
+	"strings"
+	"time"
+
+	"monolith-domain/internal/sharedkernel"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DeliveryStatus is the state of one recipient of a message in the delivery log.
+type DeliveryStatus string
+
+const (
+	DeliveryStatusQueued    DeliveryStatus = "queued"
+	DeliveryStatusScheduled DeliveryStatus = "scheduled"
+	DeliveryStatusRetrying  DeliveryStatus = "retrying"
+	DeliveryStatusSent      DeliveryStatus = "sent"
+	DeliveryStatusFailed    DeliveryStatus = "failed"
+	DeliveryStatusCancelled DeliveryStatus = "cancelled"
+	// DeliveryStatusBounced marks a recipient the message was handed over for but
+	// that a delivery status notification later reported as failed.
+	DeliveryStatusBounced DeliveryStatus = "bounced"
+)
+
+// DeliveryLogEntry records what happened to a message for one of its recipients.
+// A message with several recipients (To, Cc and Bcc) has one entry per recipient,
+// all sharing the outbox MessageID.
+type DeliveryLogEntry struct {
+	ID            uuid.UUID      `json:"id" gorm:"type:uuid;primaryKey"`
+	MessageID     uuid.UUID      `json:"message_id" gorm:"type:uuid;index;not null"`
+	Recipient     string         `json:"recipient" gorm:"index;not null"`
+	Subject       string         `json:"subject"`
+	Template      string         `json:"template,omitempty"`
+	Campaign      string         `json:"campaign,omitempty" gorm:"index"`
+	Transport     string         `json:"transport,omitempty"`
+	Status        DeliveryStatus `json:"status" gorm:"index;not null"`
+	Attempts      int            `json:"attempts" gorm:"not null;default:0"`
+	Response      string         `json:"response,omitempty" gorm:"type:text"`
+	QueuedAt      time.Time      `json:"queued_at" gorm:"index;not null"`
+	LastAttemptAt *time.Time     `json:"last_attempt_at,omitempty"`
+	SentAt        *time.Time     `json:"sent_at,omitempty"`
+	Opens         int            `json:"opens" gorm:"not null;default:0"`
+	Clicks        int            `json:"clicks" gorm:"not null;default:0"`
+	OpenedAt      *time.Time     `json:"opened_at,omitempty"`  // First open
+	ClickedAt     *time.Time     `json:"clicked_at,omitempty"` // First click
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (DeliveryLogEntry) TableName() string {
+	return "mail_delivery_log"
+}
+
+// BeforeCreate hook for GORM to set UUID
+func (e *DeliveryLogEntry) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// NewDeliveryLogEntries creates the queued (or scheduled) log entries for an outbox message.
+func NewDeliveryLogEntries(message *OutboxMessage, m Mail) []*DeliveryLogEntry {
+	queuedAt := time.Now()
+	status := DeliveryStatusQueued
+	if message.Status == OutboxStatusScheduled {
+		status = DeliveryStatusScheduled
+	}
+	recipients := m.Recipients()
+	entries := make([]*DeliveryLogEntry, 0, len(recipients))
+	for _, recipient := range recipients {
+		entries = append(entries, &DeliveryLogEntry{
+			ID:        uuid.New(),
+			MessageID: message.ID,
+			Recipient: NormalizeRecipient(recipient),
+			Subject:   m.Subject,
+			Template:  m.Template,
+			Campaign:  m.Campaign,
+			Status:    status,
+			QueuedAt:  queuedAt,
+		})
+	}
+	return entries
+}
+
+// NormalizeRecipient reduces "Name <Address>" to the lower-cased bare address, with an
+// internationalised domain in punycode, so log searches do not depend on how the caller
+// wrote it.
+func NormalizeRecipient(recipient string) string {
+	if parsed, err := sharedkernel.ParseAddress(recipient); err == nil {
+		return parsed.Email
+	}
+	if parsed, err := mail.ParseAddress(recipient); err == nil {
+		recipient = parsed.Address
+	}
+	return strings.ToLower(strings.TrimSpace(recipient))
+}
+
+// DeliveryUpdate is the outcome of a delivery attempt applied to all log entries of a message.
+type DeliveryUpdate struct {
+	Status    DeliveryStatus
+	Attempts  int
+	Transport string
+	Response  string
+	At        time.Time
+}
+
+// DeliveryLogFilter selects delivery log entries. Zero values match everything.
+type DeliveryLogFilter struct {
+	Recipient string
+	MessageID uuid.UUID
+	Status    DeliveryStatus
+	Campaign  string
+	From      time.Time // queued at or after
+	To        time.Time // queued before
+	Page      int
+	Size      int
+}
+
+type DeliveryLogRepository interface {
+	Record(entries ...*DeliveryLogEntry) error
+	Update(messageID uuid.UUID, update DeliveryUpdate) error
+	// UpdateRecipient applies an outcome to the entry of one recipient of a message,
+	// e.g. one the server refused while it accepted the others.
+	UpdateRecipient(messageID uuid.UUID, recipient string, update DeliveryUpdate) error
+	// Reassign moves the entry of one recipient of a message to another message, e.g. a
+	// retry queued for the recipients that deferred it, and applies update to it.
+	Reassign(messageID uuid.UUID, recipient string, newMessageID uuid.UUID, update DeliveryUpdate) error
+	// MarkBounced sets the entry of one recipient of a message to bounced. It returns
+	// false when the message has no entry for the recipient.
+	MarkBounced(messageID uuid.UUID, recipient, response string) (bool, error)
+	// RecordEngagement counts an open or click on the entries of a message.
+	RecordEngagement(messageID uuid.UUID, eventType TrackingEventType, at time.Time) error
+	Search(filter DeliveryLogFilter) ([]*DeliveryLogEntry, int64, error)
+}
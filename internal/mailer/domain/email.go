@@ -1,9 +1,9 @@
 package domain
 
-import "regexp"
+import "monolith-domain/internal/sharedkernel"
 
 // ValidateEmail checks if an email address is valid.
 func ValidateEmail(email string) bool {
-	re := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
-	return re.MatchString(email)
+	_, err := sharedkernel.ParseAddress(email)
+	return err == nil
 }
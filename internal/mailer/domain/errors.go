@@ -1,6 +1,103 @@
 package domain
 
-import "errors"
+import (
+	"errors"
+
+	"monolith-domain/internal/sharedkernel"
+)
 
 // ErrInvalidEmail represents an error when an email is not valid.
-var ErrInvalidEmail = errors.New("invalid email address")
+var ErrInvalidEmail = sharedkernel.ErrInvalidEmail
+
+// ErrDisposableEmail is returned for a recipient at a disposable mail provider.
+var ErrDisposableEmail = sharedkernel.ErrDisposableEmail
+
+// ErrUndeliverableDomain is returned for a recipient whose domain cannot receive mail.
+var ErrUndeliverableDomain = sharedkernel.ErrUndeliverableDomain
+
+// ErrSuspectedTypo is returned for a recipient whose domain looks like a misspelt
+// well-known mail domain, when such recipients are refused.
+var ErrSuspectedTypo = sharedkernel.ErrSuspectedTypo
+
+// ErrMessageNotFound is returned when an outbox message does not exist.
+var ErrMessageNotFound = errors.New("message not found")
+
+// ErrMessageNotDead is returned when requeueing a message that is not dead-lettered.
+var ErrMessageNotDead = errors.New("message is not dead-lettered")
+
+// ErrMessageNotScheduled is returned when rescheduling or cancelling a message that
+// is not (or no longer) waiting for its send time.
+var ErrMessageNotScheduled = errors.New("message is not scheduled")
+
+// ErrClaimLost is returned when recording the outcome of a delivery whose claim on the
+// outbox message expired and was taken over by another worker.
+var ErrClaimLost = errors.New("outbox message was reclaimed by another worker")
+
+// ErrInvalidSchedule is returned for a send time that is not in the future.
+var ErrInvalidSchedule = errors.New("send time must be in the future")
+
+// ErrTemplateNotFound is returned when an email template does not exist.
+var ErrTemplateNotFound = errors.New("template not found")
+
+// ErrTemplateExists is returned when creating a template with a name that is taken.
+var ErrTemplateExists = errors.New("template with this name already exists")
+
+// ErrInvalidTemplate is returned when a template cannot be parsed or lacks required parts.
+var ErrInvalidTemplate = errors.New("invalid template")
+
+// ErrTemplateRender is returned when a template fails to render with the given data,
+// for example because a referenced variable is missing.
+var ErrTemplateRender = errors.New("failed to render template")
+
+// ErrMissingMergeVariables is returned when recipients of a personalised bulk send do
+// not provide every variable the template uses.
+var ErrMissingMergeVariables = errors.New("recipients are missing template variables")
+
+// ErrTranslationNotFound is returned when a key has no translation in the requested
+// language nor in the fallback language.
+var ErrTranslationNotFound = errors.New("translation not found")
+
+// ErrInvalidAttachment is returned when an attachment is malformed.
+var ErrInvalidAttachment = errors.New("invalid attachment")
+
+// ErrAttachmentTooLarge is returned when attachments exceed the configured size limits.
+var ErrAttachmentTooLarge = errors.New("attachment too large")
+
+// ErrNoRecipients is returned when a mail has no To recipient.
+var ErrNoRecipients = errors.New("at least one recipient is required")
+
+// ErrInvalidHeader is returned when a header name or value could be used for header injection.
+var ErrInvalidHeader = errors.New("invalid header")
+
+// ErrRecipientSuppressed is returned when a mail is addressed to a suppressed address.
+var ErrRecipientSuppressed = errors.New("recipient is on the suppression list")
+
+// ErrSuppressionNotFound is returned when a suppression entry does not exist.
+var ErrSuppressionNotFound = errors.New("suppression not found")
+
+// ErrSuppressionExists is returned when suppressing an address that is already suppressed.
+var ErrSuppressionExists = errors.New("address is already suppressed")
+
+// ErrInvalidSuppressionReason is returned for a reason outside the known set.
+var ErrInvalidSuppressionReason = errors.New("invalid suppression reason")
+
+// ErrInvalidDSN is returned when a message is not an RFC 3464 delivery status notification.
+var ErrInvalidDSN = errors.New("not a delivery status notification")
+
+// ErrInvalidBounceSignature is returned for a posted bounce without a valid signature.
+var ErrInvalidBounceSignature = errors.New("invalid bounce signature")
+
+// ErrInvalidTrackingToken is returned for a tracking token that was not issued by us.
+var ErrInvalidTrackingToken = errors.New("invalid tracking token")
+
+// ErrIdempotencyKeyInUse is returned while the first request with an idempotency key is
+// still being processed.
+var ErrIdempotencyKeyInUse = errors.New("a request with this idempotency key is still being processed")
+
+// ErrIdempotencyKeyReused is returned when an idempotency key is sent again with a
+// different request body.
+var ErrIdempotencyKeyReused = errors.New("idempotency key was already used with a different request")
+
+// ErrIdempotencyReservationLost is returned when a request finishes after its reservation
+// of an idempotency key expired and was taken over by a retry.
+var ErrIdempotencyReservationLost = errors.New("idempotency key reservation expired and was taken over")
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"fmt"
+	"net/textproto"
+	"strings"
+)
+
+// reservedHeaders are generated by the mailer and cannot be set through Mail.Headers.
+var reservedHeaders = map[string]bool{
+	"From":                      true,
+	"Sender":                    true,
+	"To":                        true,
+	"Cc":                        true,
+	"Bcc":                       true,
+	"Reply-To":                  true,
+	"Subject":                   true,
+	"Date":                      true,
+	"Message-Id":                true,
+	"Mime-Version":              true,
+	"Content-Type":              true,
+	"Content-Transfer-Encoding": true,
+	"Return-Path":               true,
+	"Dkim-Signature":            true,
+}
+
+// Validate checks that the mail has at least one recipient and cannot inject headers.
+func (m Mail) Validate() error {
+	if len(m.To) == 0 {
+		return ErrNoRecipients
+	}
+	return ValidateHeaders(m)
+}
+
+// ValidateHeaders rejects line breaks in every value that ends up in a header and
+// custom header names that are malformed or reserved.
+func ValidateHeaders(m Mail) error {
+	fields := map[string][]string{
+		"to":        m.To,
+		"cc":        m.Cc,
+		"bcc":       m.Bcc,
+		"reply_to":  {m.ReplyTo},
+		"from_name": {m.FromName},
+		"subject":   {m.Subject},
+	}
+	for field, values := range fields {
+		for _, value := range values {
+			if strings.ContainsAny(value, "\r\n") {
+				return fmt.Errorf("%w: %s contains a line break", ErrInvalidHeader, field)
+			}
+		}
+	}
+
+	for name, value := range m.Headers {
+		if !validHeaderName(name) {
+			return fmt.Errorf("%w: %q is not a valid header name", ErrInvalidHeader, name)
+		}
+		if reservedHeaders[textproto.CanonicalMIMEHeaderKey(name)] {
+			return fmt.Errorf("%w: %q cannot be overridden", ErrInvalidHeader, name)
+		}
+		if strings.ContainsAny(value, "\r\n\x00") {
+			return fmt.Errorf("%w: value of %q contains a line break", ErrInvalidHeader, name)
+		}
+	}
+	return nil
+}
+
+// validHeaderName reports whether name consists of printable US-ASCII except ':' (RFC 5322 ftext).
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		if name[i] < 33 || name[i] > 126 || name[i] == ':' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+var (
+	htmlCommentPattern  = regexp.MustCompile(`(?s)<!--.*?-->`)
+	htmlHiddenPattern   = regexp.MustCompile(`(?is)<(script|style|head|title)\b.*?</(script|style|head|title)\s*>`)
+	htmlLinkPattern     = regexp.MustCompile(`(?is)<a\b[^>]*?\bhref\s*=\s*["']([^"']*)["'][^>]*>(.*?)</a\s*>`)
+	htmlLineBreak       = regexp.MustCompile(`(?i)<br\s*/?>`)
+	htmlListItemPattern = regexp.MustCompile(`(?i)<li\b[^>]*>`)
+	htmlBlockPattern    = regexp.MustCompile(`(?i)</?(p|div|h[1-6]|ul|ol|table|tr|blockquote|section|article|header|footer|hr)\b[^>]*>`)
+	htmlCellPattern     = regexp.MustCompile(`(?i)</t[dh]\s*>`)
+	htmlTagPattern      = regexp.MustCompile(`(?s)<[^>]*>`)
+	spaceRunPattern     = regexp.MustCompile(`[ \t\f\v]+`)
+	blankLinesPattern   = regexp.MustCompile(`\n{3,}`)
+)
+
+// HTMLToText derives a readable plain-text version of an HTML email body:
+// block elements become line breaks, list items get a dash, links keep their
+// target in parentheses and markup, scripts and styles are dropped.
+func HTMLToText(body string) string {
+	text := htmlCommentPattern.ReplaceAllString(body, "")
+	text = htmlHiddenPattern.ReplaceAllString(text, "")
+	text = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ").Replace(text)
+
+	text = htmlLinkPattern.ReplaceAllStringFunc(text, func(link string) string {
+		match := htmlLinkPattern.FindStringSubmatch(link)
+		href, label := match[1], strings.TrimSpace(htmlTagPattern.ReplaceAllString(match[2], ""))
+		if label == "" || label == href || strings.HasPrefix(href, "#") {
+			return label
+		}
+		return label + " (" + href + ")"
+	})
+
+	text = htmlLineBreak.ReplaceAllString(text, "\n")
+	text = htmlListItemPattern.ReplaceAllString(text, "\n- ")
+	text = htmlBlockPattern.ReplaceAllString(text, "\n\n")
+	text = htmlCellPattern.ReplaceAllString(text, "\t")
+	text = htmlTagPattern.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(spaceRunPattern.ReplaceAllString(line, " "))
+	}
+	text = strings.Join(lines, "\n")
+	text = blankLinesPattern.ReplaceAllString(text, "\n\n")
+
+	return strings.TrimSpace(text)
+}
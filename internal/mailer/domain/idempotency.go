@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+// IdempotencyRecord remembers the response to a request sent with an Idempotency-Key,
+// so a client retrying the request gets the same answer instead of a second email.
+// Keys are scoped per endpoint.
+type IdempotencyRecord struct {
+	Key         string    `gorm:"primaryKey;size:255"`
+	Scope       string    `gorm:"primaryKey;size:100"` // Endpoint, e.g. "POST /send-email"
+	RequestHash string    `gorm:"size:64;not null"`    // SHA-256 of the request body
+	StatusCode  int       `gorm:"not null;default:0"`  // 0 while the first request is still being processed
+	Response    []byte    `gorm:"type:bytea"`
+	CreatedAt   time.Time `gorm:"not null"`       // Identifies the reservation while the request runs
+	ExpiresAt   time.Time `gorm:"index;not null"` // End of the lock while in progress, of the replay window once completed
+}
+
+// TableName specifies the table name for GORM
+func (IdempotencyRecord) TableName() string {
+	return "mail_idempotency_keys"
+}
+
+// Completed reports whether the response of the first request has been stored.
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
+
+type IdempotencyRepository interface {
+	// Reserve stores a new in-progress record. It returns false when the key is already
+	// taken by a record that has not expired; an expired record is replaced, whether it
+	// is a completed one past its replay window or a reservation past its lock.
+	Reserve(record *IdempotencyRecord) (bool, error)
+	// Find returns nil when no record exists for the key.
+	Find(scope, key string) (*IdempotencyRecord, error)
+	// Complete stores the response and expiry of record. Like Delete, it only touches the
+	// reservation record was created for and returns ErrIdempotencyReservationLost once
+	// that reservation has been replaced.
+	Complete(record *IdempotencyRecord) error
+	// Extend moves the lock of the reservation record was created for to until.
+	Extend(record *IdempotencyRecord, until time.Time) error
+	Delete(record *IdempotencyRecord) error
+	DeleteExpired(before time.Time) (int64, error)
+}
@@ -1,16 +1,100 @@
 package domain
 
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Mail is an outgoing email. When both HTMLBody and TextBody are set the message is
+// sent as multipart/alternative so clients can pick the version they render.
+// Bcc recipients only appear in the SMTP envelope, never in the headers.
 type Mail struct {
-	To      string
-	Subject string
-	Body    string
-	IsHTML  bool
+	ID          uuid.UUID         `json:"id"` // Outbox message ID, used for a stable Message-ID across retries
+	To          []string          `json:"to"`
+	Cc          []string          `json:"cc,omitempty"`
+	Bcc         []string          `json:"bcc,omitempty"`
+	ReplyTo     string            `json:"reply_to,omitempty"`
+	FromName    string            `json:"from_name,omitempty"` // Overrides the configured sender display name
+	Headers     map[string]string `json:"headers,omitempty"`
+	Subject     string            `json:"subject"`
+	HTMLBody    string            `json:"html_body,omitempty"`
+	TextBody    string            `json:"text_body,omitempty"`
+	Attachments []Attachment      `json:"attachments,omitempty"`
+	Template    string            `json:"template,omitempty"` // Name of the template the mail was rendered from, for the delivery log
+	Campaign    string            `json:"campaign,omitempty"` // Groups mails for delivery and engagement reports
+	Track       *bool             `json:"track,omitempty"`    // Open and click tracking of the HTML body; nil uses the configured default
+	// EnvelopeTo, when not nil, restricts delivery to these addresses while the headers
+	// still show To and Cc as written, e.g. for a retry to the recipients that deferred
+	// a message or when some recipients were suppressed after the mail was queued.
+	EnvelopeTo []string `json:"envelope_to,omitempty"`
+}
+
+// Recipients returns the envelope recipients without duplicates: EnvelopeTo when set,
+// otherwise To, Cc and Bcc.
+func (m Mail) Recipients() []string {
+	lists := [][]string{m.To, m.Cc, m.Bcc}
+	if m.EnvelopeTo != nil {
+		lists = [][]string{m.EnvelopeTo}
+	}
+	seen := make(map[string]bool)
+	var recipients []string
+	for _, list := range lists {
+		for _, address := range list {
+			key := strings.ToLower(address)
+			if !seen[key] {
+				seen[key] = true
+				recipients = append(recipients, address)
+			}
+		}
+	}
+	return recipients
+}
+
+// WithTextAlternative returns the mail with a plain-text body derived from the
+// HTML body when only HTML was supplied.
+func (m Mail) WithTextAlternative() Mail {
+	if m.HTMLBody != "" && m.TextBody == "" {
+		m.TextBody = HTMLToText(m.HTMLBody)
+	}
+	return m
+}
+
+// Attachment is a file carried by a Mail. When ContentID is set the file is an
+// inline part that the HTML body references as "cid:<ContentID>".
+type Attachment struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Content     []byte `json:"content"`
+	ContentID   string `json:"content_id,omitempty"`
+}
+
+// IsInline reports whether the attachment is embedded in the body rather than attached.
+func (a Attachment) IsInline() bool {
+	return a.ContentID != ""
+}
+
+// AttachmentLimits bounds the size of attachments accepted for a single Mail, in bytes.
+type AttachmentLimits struct {
+	MaxSize      int64
+	MaxTotalSize int64
+}
+
+// DeliveryReceipt describes a hand-over to a transport. Transports return it on
+// failure too, when they know which route was used.
+type DeliveryReceipt struct {
+	Transport string // e.g. "smtp://smtp.example.com:587", "sendmail" or a relay name
+	MessageID string // Message-ID header of the delivered message
+	Response  string // Final reply of the remote side, e.g. "250 2.0.0 Ok: queued as 4F2"
+	// Rejected holds a DeliveryError per recipient the server refused. The message
+	// went to the other recipients unless the send failed because all were refused.
+	Rejected []*DeliveryError
 }
 
 type MailerRepository interface {
-	Send(mail Mail) error
+	Send(mail Mail) (*DeliveryReceipt, error)
 }
 
 type MailerService interface {
-	SendMail(to string, subject string, body string, isHTML bool) error
-} 
\ No newline at end of file
+	SendMail(mail Mail) (uuid.UUID, error)
+}
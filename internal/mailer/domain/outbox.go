@@ -0,0 +1,129 @@
+package domain
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// OutboxStatus describes where a queued message is in its delivery lifecycle.
+type OutboxStatus string
+
+const (
+	OutboxStatusPending    OutboxStatus = "pending"
+	OutboxStatusProcessing OutboxStatus = "processing"
+	OutboxStatusSent       OutboxStatus = "sent"
+	// OutboxStatusScheduled marks a message held back until its send time. Once due it
+	// is claimed like a pending message; until then it can be rescheduled or cancelled.
+	OutboxStatusScheduled OutboxStatus = "scheduled"
+	// OutboxStatusCancelled marks a scheduled message that was cancelled before it went out.
+	OutboxStatusCancelled OutboxStatus = "cancelled"
+	// OutboxStatusDead marks a dead-lettered message: it failed permanently or ran out
+	// of attempts and will not be retried unless requeued.
+	OutboxStatusDead OutboxStatus = "dead"
+)
+
+// OutboxMessage is a persisted Mail waiting to be (or already) delivered by the dispatcher.
+type OutboxMessage struct {
+	ID            uuid.UUID    `json:"id" gorm:"type:uuid;primaryKey"`
+	Recipient     string       `json:"recipient" gorm:"index;not null"`
+	Subject       string       `json:"subject"`
+	Payload       []byte       `json:"-" gorm:"type:jsonb;not null"`
+	Status        OutboxStatus `json:"status" gorm:"index;not null"`
+	Attempts      int          `json:"attempts" gorm:"not null;default:0"`
+	LastError     string       `json:"last_error,omitempty" gorm:"type:text"`
+	NextAttemptAt time.Time    `json:"next_attempt_at" gorm:"index;not null"`
+	ScheduledAt   *time.Time   `json:"scheduled_at,omitempty"` // Requested send time of a scheduled message
+	LockedAt      *time.Time   `json:"-"`
+	SentAt        *time.Time   `json:"sent_at,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (OutboxMessage) TableName() string {
+	return "mail_outbox"
+}
+
+// BeforeCreate hook for GORM to set UUID
+func (m *OutboxMessage) BeforeCreate(tx *gorm.DB) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	return nil
+}
+
+// NewOutboxMessage wraps a Mail into a pending outbox entry. The mail is stamped with
+// the entry's ID so every delivery attempt carries the same Message-ID.
+func NewOutboxMessage(mail Mail) (*OutboxMessage, error) {
+	mail.ID = uuid.New()
+	payload, err := json.Marshal(mail)
+	if err != nil {
+		return nil, err
+	}
+	return &OutboxMessage{
+		ID:            mail.ID,
+		Recipient:     strings.Join(mail.To, ", "),
+		Subject:       mail.Subject,
+		Payload:       payload,
+		Status:        OutboxStatusPending,
+		NextAttemptAt: time.Now(),
+	}, nil
+}
+
+// Schedule holds a freshly created message back until at.
+func (m *OutboxMessage) Schedule(at time.Time) {
+	m.Status = OutboxStatusScheduled
+	m.ScheduledAt = &at
+	m.NextAttemptAt = at
+}
+
+// Claim marks a freshly created message as taken by the caller, so it can be stored
+// and delivered inline without a dispatcher worker picking it up as well.
+func (m *OutboxMessage) Claim(now time.Time) {
+	// Postgres keeps microseconds; the stored lock has to compare equal to this one
+	now = now.Truncate(time.Microsecond)
+	m.Status = OutboxStatusProcessing
+	m.LockedAt = &now
+	m.Attempts++
+}
+
+// Mail decodes the stored payload back into a Mail.
+func (m *OutboxMessage) Mail() (Mail, error) {
+	var mail Mail
+	err := json.Unmarshal(m.Payload, &mail)
+	return mail, err
+}
+
+type OutboxRepository interface {
+	Enqueue(messages ...*OutboxMessage) error
+	// ClaimPending locks up to limit pending or scheduled messages that are due for delivery.
+	// Messages stuck in processing for longer than lockTimeout (e.g. after a crash) are claimed again.
+	ClaimPending(limit int, lockTimeout time.Duration) ([]*OutboxMessage, error)
+	// MarkSent, MarkRetry, MarkDead and Defer record the outcome of a claimed message.
+	// They only apply while the message is still held under the claim it was returned
+	// with, and return ErrClaimLost once the lock expired and another worker took over.
+	MarkSent(message *OutboxMessage) error
+	// MarkRetry puts the message back into pending, due again at nextAttemptAt.
+	MarkRetry(message *OutboxMessage, reason string, nextAttemptAt time.Time) error
+	MarkDead(message *OutboxMessage, reason string) error
+	// Relock renews the claim on message so its lock timeout counts from now, e.g. right
+	// before a message claimed earlier is delivered inline.
+	Relock(message *OutboxMessage) error
+	// Defer puts a claimed message back into pending until the given time without
+	// counting the claim as a delivery attempt, e.g. when a send rate limit is reached.
+	Defer(message *OutboxMessage, until time.Time) error
+	// Requeue moves a dead-lettered message back to pending with a fresh attempt budget.
+	Requeue(id uuid.UUID) error
+	FindByID(id uuid.UUID) (*OutboxMessage, error)
+	FindAllDead(page, size int) ([]*OutboxMessage, int64, error)
+	// FindAllScheduled returns the messages still waiting for their send time, soonest first.
+	FindAllScheduled(page, size int) ([]*OutboxMessage, int64, error)
+	// Reschedule moves the send time of a message that is still scheduled.
+	Reschedule(id uuid.UUID, at time.Time) error
+	// Cancel stops a message that is still scheduled from being sent.
+	Cancel(id uuid.UUID) error
+}
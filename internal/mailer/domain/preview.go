@@ -0,0 +1,16 @@
+package domain
+
+// MailPreview is a mail built exactly as it would be sent, without sending it.
+type MailPreview struct {
+	Subject  string   `json:"subject"`
+	HTMLBody string   `json:"html_body,omitempty"`
+	TextBody string   `json:"text_body,omitempty"`
+	Raw      string   `json:"raw"`  // Complete RFC 5322 message, including DKIM signature and tracking
+	Size     int      `json:"size"` // Size of Raw in bytes
+	Warnings []string `json:"warnings"`
+}
+
+// MessageRenderer renders a Mail to its wire format, the way the transports do.
+type MessageRenderer interface {
+	Render(mail Mail) ([]byte, error)
+}
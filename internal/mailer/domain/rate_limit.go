@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"math"
+	"strings"
+)
+
+// RateLimit is a sustained send rate with a burst allowance. A zero PerSecond means
+// no limit.
+type RateLimit struct {
+	PerSecond float64
+	Burst     int
+}
+
+// Enabled reports whether the limit restricts anything.
+func (l RateLimit) Enabled() bool {
+	return l.PerSecond > 0
+}
+
+// BurstSize returns Burst, or one second worth of sends when Burst is not set.
+func (l RateLimit) BurstSize() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return int(math.Max(1, math.Ceil(l.PerSecond)))
+}
+
+// RecipientDomain returns the lower-cased domain of an address, or "" if it has none.
+func RecipientDomain(recipient string) string {
+	address := NormalizeRecipient(recipient)
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return ""
+	}
+	return address[at+1:]
+}
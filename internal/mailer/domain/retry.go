@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy decides how often and how quickly transient delivery failures are retried.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// Exhausted reports whether a message that has been tried attempts times should be dead-lettered.
+func (p RetryPolicy) Exhausted(attempts int) bool {
+	return attempts >= p.MaxAttempts
+}
+
+// Backoff returns the delay before the next try after attempts failures:
+// BaseDelay doubled per attempt, capped at MaxDelay, plus up to 20% jitter so
+// messages that failed together don't all retry at the same instant.
+func (p RetryPolicy) Backoff(attempts int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempts && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay + rand.N(delay/5+1)
+}
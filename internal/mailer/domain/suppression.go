@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SuppressionReason says why an address must not be mailed.
+type SuppressionReason string
+
+const (
+	SuppressionReasonHardBounce   SuppressionReason = "hard_bounce"
+	SuppressionReasonComplaint    SuppressionReason = "complaint"
+	SuppressionReasonManual       SuppressionReason = "manual"
+	SuppressionReasonUnsubscribed SuppressionReason = "unsubscribed"
+)
+
+// Valid reports whether r is one of the known reasons.
+func (r SuppressionReason) Valid() bool {
+	switch r {
+	case SuppressionReasonHardBounce, SuppressionReasonComplaint, SuppressionReasonManual, SuppressionReasonUnsubscribed:
+		return true
+	}
+	return false
+}
+
+// Suppression is an address that MailerService refuses to send to.
+type Suppression struct {
+	ID        uuid.UUID         `json:"id" gorm:"type:uuid;primaryKey"`
+	Email     string            `json:"email" gorm:"uniqueIndex;not null"`
+	Reason    SuppressionReason `json:"reason" gorm:"index;not null"`
+	Detail    string            `json:"detail,omitempty" gorm:"type:text"`
+	MessageID *uuid.UUID        `json:"message_id,omitempty" gorm:"type:uuid"` // Message whose failure caused the entry, if any
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (Suppression) TableName() string {
+	return "mail_suppressions"
+}
+
+// BeforeCreate hook for GORM to set UUID
+func (s *Suppression) BeforeCreate(tx *gorm.DB) error {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return nil
+}
+
+// NewSuppression creates an entry for email, normalised like delivery log recipients.
+func NewSuppression(email string, reason SuppressionReason, detail string) (*Suppression, error) {
+	if !reason.Valid() {
+		return nil, ErrInvalidSuppressionReason
+	}
+	email = NormalizeRecipient(email)
+	if !ValidateEmail(email) {
+		return nil, ErrInvalidEmail
+	}
+	return &Suppression{ID: uuid.New(), Email: email, Reason: reason, Detail: detail}, nil
+}
+
+type SuppressionRepository interface {
+	// Create stores a new entry and returns ErrSuppressionExists if the address is taken.
+	Create(suppression *Suppression) error
+	// CreateIfMissing stores an entry unless the address is already suppressed.
+	CreateIfMissing(suppression *Suppression) error
+	Update(suppression *Suppression) error
+	Delete(id uuid.UUID) error
+	FindByID(id uuid.UUID) (*Suppression, error)
+	// FindByEmails returns the entries for the given normalised addresses.
+	FindByEmails(emails []string) ([]*Suppression, error)
+	FindAll(reason SuppressionReason, page, size int) ([]*Suppression, int64, error)
+}
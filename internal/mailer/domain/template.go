@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EmailTemplate is a named, reusable email. Subject and TextBody are rendered with
+// text/template, HTMLBody with html/template so data values are escaped.
+type EmailTemplate struct {
+	ID        uuid.UUID `json:"id" gorm:"type:uuid;primaryKey"`
+	Name      string    `json:"name" gorm:"uniqueIndex;not null"`
+	Subject   string    `json:"subject" gorm:"type:text;not null"`
+	HTMLBody  string    `json:"html_body" gorm:"type:text"`
+	TextBody  string    `json:"text_body" gorm:"type:text"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for GORM
+func (EmailTemplate) TableName() string {
+	return "email_templates"
+}
+
+// BeforeCreate hook for GORM to set UUID
+func (t *EmailTemplate) BeforeCreate(tx *gorm.DB) error {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return nil
+}
+
+// RenderedTemplate is the output of rendering an EmailTemplate with data.
+type RenderedTemplate struct {
+	Subject  string `json:"subject"`
+	HTMLBody string `json:"html_body,omitempty"`
+	TextBody string `json:"text_body,omitempty"`
+}
+
+type TemplateRepository interface {
+	Create(template *EmailTemplate) error
+	Update(template *EmailTemplate) error
+	Delete(id uuid.UUID) error
+	FindByID(id uuid.UUID) (*EmailTemplate, error)
+	FindByName(name string) (*EmailTemplate, error)
+	FindAll(page, size int) ([]*EmailTemplate, int64, error)
+}
@@ -0,0 +1,140 @@
+package domain
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TrackingEventType distinguishes opens from clicks.
+type TrackingEventType string
+
+const (
+	TrackingEventOpen  TrackingEventType = "open"
+	TrackingEventClick TrackingEventType = "click"
+)
+
+// TrackingEvent is one open (pixel load) or click (link redirect) of a tracked message.
+type TrackingEvent struct {
+	ID        uuid.UUID         `json:"id" gorm:"type:uuid;primaryKey"`
+	MessageID uuid.UUID         `json:"message_id" gorm:"type:uuid;index;not null"`
+	Type      TrackingEventType `json:"type" gorm:"index;not null"`
+	URL       string            `json:"url,omitempty" gorm:"type:text"`
+	IP        string            `json:"ip,omitempty"`
+	UserAgent string            `json:"user_agent,omitempty" gorm:"type:text"`
+	CreatedAt time.Time         `json:"created_at" gorm:"index"`
+}
+
+// TableName specifies the table name for GORM
+func (TrackingEvent) TableName() string {
+	return "mail_tracking_events"
+}
+
+// BeforeCreate hook for GORM to set UUID
+func (e *TrackingEvent) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
+}
+
+// TrackingFilter selects the delivery log entries engagement statistics are computed
+// for. Zero values match everything.
+type TrackingFilter struct {
+	MessageID uuid.UUID
+	Campaign  string
+	Template  string
+}
+
+// LinkStats counts the clicks on one link.
+type LinkStats struct {
+	URL    string `json:"url"`
+	Clicks int64  `json:"clicks"`
+}
+
+// TrackingStats summarizes the engagement of the selected messages. Opened and Clicked
+// count recipients with at least one event; Opens and Clicks count every event.
+type TrackingStats struct {
+	Recipients int64       `json:"recipients"`
+	Opened     int64       `json:"opened"`
+	Clicked    int64       `json:"clicked"`
+	Opens      int64       `json:"opens"`
+	Clicks     int64       `json:"clicks"`
+	Links      []LinkStats `json:"links"`
+}
+
+type TrackingEventRepository interface {
+	Record(event *TrackingEvent) error
+	Stats(filter TrackingFilter) (*TrackingStats, error)
+}
+
+// trackingMACSize is the length of the truncated HMAC in a tracking token.
+const trackingMACSize = 16
+
+// TrackingSigner issues and verifies the tokens in tracking pixel and link URLs. A
+// token carries the message ID (and the target URL for links) and an HMAC, so the
+// redirect endpoint cannot be abused to send people to arbitrary sites.
+type TrackingSigner struct {
+	secret []byte
+}
+
+func NewTrackingSigner(secret string) *TrackingSigner {
+	return &TrackingSigner{secret: []byte(secret)}
+}
+
+// OpenToken returns the token of the tracking pixel of a message.
+func (s *TrackingSigner) OpenToken(messageID uuid.UUID) string {
+	return s.sign(messageID[:])
+}
+
+// LinkToken returns the token of a tracked link of a message.
+func (s *TrackingSigner) LinkToken(messageID uuid.UUID, url string) string {
+	return s.sign(append(messageID[:], url...))
+}
+
+// ParseOpenToken returns the message ID of a pixel token.
+func (s *TrackingSigner) ParseOpenToken(token string) (uuid.UUID, error) {
+	payload, err := s.verify(token)
+	if err != nil || len(payload) != len(uuid.UUID{}) {
+		return uuid.Nil, ErrInvalidTrackingToken
+	}
+	return uuid.UUID(payload), nil
+}
+
+// ParseLinkToken returns the message ID and target URL of a link token.
+func (s *TrackingSigner) ParseLinkToken(token string) (uuid.UUID, string, error) {
+	payload, err := s.verify(token)
+	if err != nil || len(payload) <= len(uuid.UUID{}) {
+		return uuid.Nil, "", ErrInvalidTrackingToken
+	}
+	return uuid.UUID(payload[:16]), string(payload[16:]), nil
+}
+
+func (s *TrackingSigner) sign(payload []byte) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(append(payload, mac.Sum(nil)[:trackingMACSize]...))
+}
+
+// verify checks token; a nil signer (tracking disabled) accepts nothing.
+func (s *TrackingSigner) verify(token string) ([]byte, error) {
+	if s == nil {
+		return nil, ErrInvalidTrackingToken
+	}
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) <= trackingMACSize {
+		return nil, ErrInvalidTrackingToken
+	}
+	payload, sum := data[:len(data)-trackingMACSize], data[len(data)-trackingMACSize:]
+
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(payload)
+	if !hmac.Equal(sum, mac.Sum(nil)[:trackingMACSize]) {
+		return nil, ErrInvalidTrackingToken
+	}
+	return payload, nil
+}
@@ -0,0 +1,7 @@
+package domain
+
+// TranslationProvider resolves translated strings for template rendering.
+// Implementations fall back to a default language when langCode has no entry.
+type TranslationProvider interface {
+	Translate(key, langCode string) (string, error)
+}
@@ -0,0 +1,143 @@
+package infrastructure
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"monolith-domain/internal/mailer/domain"
+	"monolith-domain/internal/mailer/infrastructure/smtptest"
+
+	"github.com/emersion/go-msgauth/dkim"
+)
+
+// TestDKIMSignatureVerifies sends a signed message through the SMTP mailer and checks
+// the received copy with an independent DKIM implementation, serving the public key as
+// the DNS record a receiver would look up.
+func TestDKIMSignatureVerifies(t *testing.T) {
+	for _, keyType := range []string{"rsa", "ed25519"} {
+		t.Run(keyType, func(t *testing.T) {
+			signer, publicKey := generateDKIMSigner(t, keyType)
+			record := dkimRecord(t, publicKey)
+
+			server, err := smtptest.NewServer(smtptest.ModePlain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer server.Close()
+
+			builder := NewMessageBuilder("sender@example.com", "dkim test").SignWith(signer)
+			mailer, err := NewSMTPMailer(server.Host(), server.Port(), "", "", TLSOptions{Mode: TLSModePlain}, PoolOptions{}, builder)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = mailer.Send(domain.Mail{
+				To:       []string{"Ünïcode Recipient <recipient@example.com>", "second@example.com"},
+				Cc:       []string{"copy@example.com"},
+				Subject:  "DKIM check with a subject long enough to be folded over more than one header line",
+				HTMLBody: "<p>Hello   <b>world</b></p>\n\n",
+				TextBody: "Hello   world  \n\n\n",
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			messages := server.Messages()
+			if len(messages) != 1 {
+				t.Fatalf("expected 1 message, server received %d", len(messages))
+			}
+			data := messages[0].Data
+			if !bytes.HasPrefix(data, []byte("DKIM-Signature: ")) {
+				t.Fatal("message does not start with a DKIM-Signature header")
+			}
+
+			if err := verifyDKIM(data, record); err != nil {
+				t.Fatalf("signature does not verify: %v", err)
+			}
+			if verifyDKIM(bytes.Replace(data, []byte("Hello"), []byte("Hallo"), 1), record) == nil {
+				t.Error("signature still verifies after the body was changed")
+			}
+			if verifyDKIM(bytes.Replace(data, []byte("Subject: DKIM"), []byte("Subject: DKIX"), 1), record) == nil {
+				t.Error("signature still verifies after the subject was changed")
+			}
+		})
+	}
+}
+
+// generateDKIMSigner creates a key of the given type, writes it to a PEM file and
+// loads it the same way the server does.
+func generateDKIMSigner(t *testing.T, keyType string) (*DKIMSigner, crypto.PublicKey) {
+	t.Helper()
+	var key crypto.Signer
+	var err error
+	switch keyType {
+	case "rsa":
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ed25519":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		t.Fatalf("unknown key type %q", keyType)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "dkim.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := LoadDKIMSigner("example.com", "test", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer, key.Public()
+}
+
+// dkimRecord returns the TXT record publishing publicKey under the selector.
+func dkimRecord(t *testing.T, publicKey crypto.PublicKey) string {
+	t.Helper()
+	switch key := publicKey.(type) {
+	case *rsa.PublicKey:
+		der, err := x509.MarshalPKIXPublicKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "v=DKIM1; k=rsa; p=" + base64.StdEncoding.EncodeToString(der)
+	case ed25519.PublicKey:
+		return "v=DKIM1; k=ed25519; p=" + base64.StdEncoding.EncodeToString(key)
+	}
+	t.Fatalf("unsupported public key type %T", publicKey)
+	return ""
+}
+
+func verifyDKIM(data []byte, record string) error {
+	verifications, err := dkim.VerifyWithOptions(bytes.NewReader(data), &dkim.VerifyOptions{
+		LookupTXT: func(domain string) ([]string, error) {
+			if domain != "test._domainkey.example.com" {
+				return nil, fmt.Errorf("unexpected DKIM key lookup for %s", domain)
+			}
+			return []string{record}, nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if len(verifications) != 1 {
+		return fmt.Errorf("expected 1 signature, found %d", len(verifications))
+	}
+	return verifications[0].Err
+}
@@ -0,0 +1,173 @@
+package infrastructure
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"strings"
+
+	"monolith-domain/internal/mailer/domain"
+)
+
+// DSNParser reads RFC 3464 delivery status notifications: a multipart/report with
+// report-type=delivery-status, holding a message/delivery-status part with one field
+// block per recipient and usually the returned message or its headers.
+type DSNParser struct{}
+
+func NewDSNParser() *DSNParser {
+	return &DSNParser{}
+}
+
+// dsnReport collects the parts of a notification that matter while walking it.
+type dsnReport struct {
+	status            []byte
+	originalMessageID string
+}
+
+// Parse returns the per-recipient reports of a raw notification. The outbox message is
+// identified from the Message-ID of the returned message when it was sent by us.
+func (p *DSNParser) Parse(raw []byte) ([]domain.Bounce, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidDSN, err)
+	}
+
+	var report dsnReport
+	if err := report.walk(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body); err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidDSN, err)
+	}
+	if report.status == nil {
+		return nil, fmt.Errorf("%w: no message/delivery-status part", domain.ErrInvalidDSN)
+	}
+
+	blocks, err := readFieldBlocks(report.status)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrInvalidDSN, err)
+	}
+	if len(blocks) < 2 {
+		return nil, fmt.Errorf("%w: no per-recipient fields", domain.ErrInvalidDSN)
+	}
+
+	messageID, _ := domain.MessageIDFromHeader(report.originalMessageID)
+	remoteMTA := dsnValue(blocks[0].Get("Reporting-MTA"))
+
+	// The first block holds the per-message fields, every following one a recipient
+	bounces := make([]domain.Bounce, 0, len(blocks)-1)
+	for _, fields := range blocks[1:] {
+		recipient := dsnValue(fields.Get("Final-Recipient"))
+		if recipient == "" {
+			recipient = dsnValue(fields.Get("Original-Recipient"))
+		}
+		if recipient == "" {
+			continue
+		}
+		bounce := domain.Bounce{
+			Recipient:         domain.NormalizeRecipient(recipient),
+			Action:            strings.ToLower(strings.TrimSpace(fields.Get("Action"))),
+			Status:            firstField(fields.Get("Status")),
+			DiagnosticCode:    dsnValue(fields.Get("Diagnostic-Code")),
+			RemoteMTA:         dsnValue(fields.Get("Remote-MTA")),
+			OriginalMessageID: report.originalMessageID,
+			MessageID:         messageID,
+		}
+		if bounce.RemoteMTA == "" {
+			bounce.RemoteMTA = remoteMTA
+		}
+		bounces = append(bounces, bounce)
+	}
+	if len(bounces) == 0 {
+		return nil, fmt.Errorf("%w: no recipient reported", domain.ErrInvalidDSN)
+	}
+	return bounces, nil
+}
+
+// walk descends into multipart bodies, since some MTAs wrap the report in another
+// multipart, and keeps the delivery status and the returned message's Message-ID.
+func (r *dsnReport) walk(contentType, transferEncoding string, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	switch {
+	case strings.HasPrefix(mediaType, "multipart/"):
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := r.walk(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part); err != nil {
+				return err
+			}
+		}
+	case mediaType == "message/delivery-status":
+		data, err := io.ReadAll(decodeTransfer(transferEncoding, body))
+		if err != nil {
+			return err
+		}
+		r.status = data
+	case mediaType == "message/rfc822", mediaType == "text/rfc822-headers":
+		header, err := textproto.NewReader(bufio.NewReader(decodeTransfer(transferEncoding, body))).ReadMIMEHeader()
+		if err != nil && len(header) == 0 {
+			return nil
+		}
+		if r.originalMessageID == "" {
+			r.originalMessageID = strings.TrimSpace(header.Get("Message-Id"))
+		}
+	}
+	return nil
+}
+
+// decodeTransfer undoes base64; multipart.Reader already decodes quoted-printable parts.
+func decodeTransfer(encoding string, body io.Reader) io.Reader {
+	if strings.EqualFold(strings.TrimSpace(encoding), "base64") {
+		return base64.NewDecoder(base64.StdEncoding, body)
+	}
+	return body
+}
+
+// readFieldBlocks splits a message/delivery-status body into its blank-line
+// separated groups of header-style fields.
+func readFieldBlocks(data []byte) ([]textproto.MIMEHeader, error) {
+	reader := textproto.NewReader(bufio.NewReader(bytes.NewReader(data)))
+	var blocks []textproto.MIMEHeader
+	for {
+		fields, err := reader.ReadMIMEHeader()
+		if len(fields) > 0 {
+			blocks = append(blocks, fields)
+		}
+		if err == io.EOF {
+			return blocks, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// dsnValue strips the type prefix of typed fields such as "rfc822; user@example.com"
+// or "smtp; 550 5.1.1 User unknown".
+func dsnValue(value string) string {
+	if i := strings.Index(value, ";"); i >= 0 {
+		value = value[i+1:]
+	}
+	return strings.TrimSpace(value)
+}
+
+// firstField returns a status code without a trailing comment, e.g. "5.1.1 (bad mailbox)".
+func firstField(value string) string {
+	if fields := strings.Fields(value); len(fields) > 0 {
+		return fields[0]
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+package infrastructure
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"monolith-domain/internal/mailer/domain"
+	"monolith-domain/pkg/observability"
+
+	"go.uber.org/zap"
+)
+
+// FileMailer writes every message as an .eml file into a directory instead of sending it.
+type FileMailer struct {
+	dir     string
+	builder *MessageBuilder
+	logger  *zap.Logger
+}
+
+// NewFileMailer creates a file-drop mailer, creating dir if it does not exist.
+func NewFileMailer(dir string, builder *MessageBuilder) (*FileMailer, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("file mailer directory is not configured")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail drop directory: %w", err)
+	}
+	return &FileMailer{dir: dir, builder: builder, logger: observability.GetLogger()}, nil
+}
+
+// Send writes the message to <dir>/<timestamp>-<message id>.eml. The file is written
+// under a temporary name first so readers never see a partial message.
+func (m *FileMailer) Send(mail domain.Mail) (*domain.DeliveryReceipt, error) {
+	receipt := &domain.DeliveryReceipt{Transport: "file://" + m.dir}
+	message, err := m.builder.Build(mail)
+	if err != nil {
+		return receipt, fmt.Errorf("failed to build email: %w", &domain.DeliveryError{Permanent: true, Err: err})
+	}
+	receipt.MessageID = message.MessageID
+
+	id := strings.Trim(message.MessageID, "<>")
+	if at := strings.Index(id, "@"); at >= 0 {
+		id = id[:at]
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), id)
+
+	tmp, err := os.CreateTemp(m.dir, ".tmp-*.eml")
+	if err != nil {
+		return receipt, fmt.Errorf("failed to send email: %w", &domain.DeliveryError{Err: err})
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(message.Data); err != nil {
+		tmp.Close()
+		return receipt, fmt.Errorf("failed to send email: %w", &domain.DeliveryError{Err: err})
+	}
+	if err := tmp.Close(); err != nil {
+		return receipt, fmt.Errorf("failed to send email: %w", &domain.DeliveryError{Err: err})
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(m.dir, name)); err != nil {
+		return receipt, fmt.Errorf("failed to send email: %w", &domain.DeliveryError{Err: err})
+	}
+
+	m.logger.Info("Email written to mail drop",
+		zap.Strings("to", mail.To),
+		zap.String("message_id", message.MessageID),
+		zap.String("file", name),
+	)
+	receipt.Response = "written to " + name
+	return receipt, nil
+}
@@ -0,0 +1,46 @@
+package infrastructure
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMailerWritesOneFilePerMessage(t *testing.T) {
+	drop := filepath.Join(t.TempDir(), "drop")
+	mailer, err := NewFileMailer(drop, NewMessageBuilder("sender@example.com", "test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for i := 0; i < 2; i++ {
+		receipt, err := mailer.Send(testMail)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, receipt.MessageID)
+	}
+
+	entries, err := os.ReadDir(drop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 files, found %d", len(entries))
+	}
+	for i, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") || !strings.HasSuffix(entry.Name(), ".eml") {
+			t.Errorf("unexpected file %s in the mail drop", entry.Name())
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(drop, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Contains(data, []byte("Message-ID: "+ids[i]+"\r\n")) {
+			t.Errorf("%s does not carry Message-ID %s", entry.Name(), ids[i])
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package infrastructure
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"monolith-domain/internal/mailer/domain"
+	"monolith-domain/pkg/observability"
+
+	"go.uber.org/zap"
+)
+
+const defaultHTTPMailerTimeout = 30 * time.Second
+
+// httpMailRequest is the JSON document posted to the HTTP API. Raw carries the fully
+// built (and possibly DKIM signed) RFC 5322 message for APIs that accept raw MIME;
+// html_body is the same HTML, so tracking works with either.
+type httpMailRequest struct {
+	MessageID   string                  `json:"message_id"`
+	From        string                  `json:"from"`
+	FromName    string                  `json:"from_name,omitempty"`
+	To          []string                `json:"to"`
+	Cc          []string                `json:"cc,omitempty"`
+	Bcc         []string                `json:"bcc,omitempty"`
+	Recipients  []string                `json:"recipients"` // Addresses to deliver to; fewer than to, cc and bcc when only some are retried
+	ReplyTo     string                  `json:"reply_to,omitempty"`
+	Subject     string                  `json:"subject"`
+	HTMLBody    string                  `json:"html_body,omitempty"`
+	TextBody    string                  `json:"text_body,omitempty"`
+	Headers     map[string]string       `json:"headers,omitempty"`
+	Attachments []httpAttachmentRequest `json:"attachments,omitempty"`
+	Raw         []byte                  `json:"raw"`
+}
+
+type httpAttachmentRequest struct {
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Content     []byte `json:"content"`
+	ContentID   string `json:"content_id,omitempty"`
+}
+
+// HTTPMailer delivers mail by POSTing JSON to a mail provider API (or a local stub).
+type HTTPMailer struct {
+	endpoint string
+	token    string
+	client   *http.Client
+	builder  *MessageBuilder
+	logger   *zap.Logger
+}
+
+// NewHTTPMailer creates a mailer posting to endpoint. When token is set it is sent
+// as a bearer token; a zero timeout uses 30 seconds.
+func NewHTTPMailer(endpoint, token string, timeout time.Duration, builder *MessageBuilder) (*HTTPMailer, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("http mailer endpoint is not configured")
+	}
+	if timeout <= 0 {
+		timeout = defaultHTTPMailerTimeout
+	}
+	return &HTTPMailer{
+		endpoint: endpoint,
+		token:    token,
+		client:   &http.Client{Timeout: timeout},
+		builder:  builder,
+		logger:   observability.GetLogger(),
+	}, nil
+}
+
+// Send posts the message. 2xx responses are success; 408, 429 and 5xx responses and
+// network errors are transient, any other status is a permanent failure.
+func (m *HTTPMailer) Send(mail domain.Mail) (*domain.DeliveryReceipt, error) {
+	receipt := &domain.DeliveryReceipt{Transport: m.endpoint}
+	message, err := m.builder.Build(mail)
+	if err != nil {
+		return receipt, fmt.Errorf("failed to build email: %w", &domain.DeliveryError{Permanent: true, Err: err})
+	}
+	receipt.MessageID = message.MessageID
+
+	fromName := m.builder.fromName
+	if mail.FromName != "" {
+		fromName = mail.FromName
+	}
+	payload := httpMailRequest{
+		MessageID:  message.MessageID,
+		From:       message.From,
+		FromName:   fromName,
+		To:         mail.To,
+		Cc:         mail.Cc,
+		Bcc:        mail.Bcc,
+		Recipients: message.Recipients,
+		ReplyTo:    mail.ReplyTo,
+		Subject:    mail.Subject,
+		HTMLBody:   message.HTMLBody,
+		TextBody:   mail.TextBody,
+		Headers:    mail.Headers,
+		Raw:        message.Data,
+	}
+	for _, attachment := range mail.Attachments {
+		payload.Attachments = append(payload.Attachments, httpAttachmentRequest{
+			Filename:    attachment.Filename,
+			ContentType: attachment.ContentType,
+			Content:     attachment.Content,
+			ContentID:   attachment.ContentID,
+		})
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return receipt, fmt.Errorf("failed to build email: %w", &domain.DeliveryError{Permanent: true, Err: err})
+	}
+
+	req, err := http.NewRequest(http.MethodPost, m.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return receipt, fmt.Errorf("failed to send email: %w", &domain.DeliveryError{Permanent: true, Err: err})
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.token != "" {
+		req.Header.Set("Authorization", "Bearer "+m.token)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		m.logger.Error("Failed to send email",
+			zap.Strings("to", mail.To),
+			zap.String("endpoint", m.endpoint),
+			zap.Error(err),
+		)
+		return receipt, fmt.Errorf("failed to send email: %w", &domain.DeliveryError{Err: err})
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		err := fmt.Errorf("mail API responded with %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+		m.logger.Error("Failed to send email",
+			zap.Strings("to", mail.To),
+			zap.String("endpoint", m.endpoint),
+			zap.Error(err),
+		)
+		return receipt, fmt.Errorf("failed to send email: %w", &domain.DeliveryError{Permanent: isPermanentHTTPStatus(resp.StatusCode), Err: err})
+	}
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	receipt.Response = strings.TrimSpace(resp.Status + " " + string(detail))
+
+	m.logger.Info("Email sent successfully",
+		zap.Strings("to", mail.To),
+		zap.String("message_id", message.MessageID),
+		zap.String("subject", mail.Subject),
+		zap.String("transport", "http"),
+	)
+	return receipt, nil
+}
+
+func isPermanentHTTPStatus(status int) bool {
+	switch {
+	case status == http.StatusRequestTimeout, status == http.StatusTooManyRequests:
+		return false
+	case status >= 500:
+		return false
+	}
+	return true
+}
@@ -0,0 +1,141 @@
+package infrastructure
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"monolith-domain/internal/mailer/domain"
+
+	"github.com/google/uuid"
+)
+
+// httpRequest is the part of the HTTP transport's JSON document the tests look at.
+type httpRequest struct {
+	MessageID string   `json:"message_id"`
+	From      string   `json:"from"`
+	To        []string `json:"to"`
+	Subject   string   `json:"subject"`
+	HTMLBody  string   `json:"html_body"`
+	Raw       []byte   `json:"raw"`
+}
+
+func TestHTTPMailerPostsMessage(t *testing.T) {
+	var got httpRequest
+	var authorization, method string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method, authorization = r.Method, r.Header.Get("Authorization")
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &got); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+		io.WriteString(w, `{"id":"queued-1"}`)
+	}))
+	defer server.Close()
+
+	mailer, err := NewHTTPMailer(server.URL, "secret-token", time.Minute, NewMessageBuilder("sender@example.com", "test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt, err := mailer.Send(testMail)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if method != http.MethodPost || authorization != "Bearer secret-token" {
+		t.Errorf("expected a POST with the bearer token, got %s with %q", method, authorization)
+	}
+	if got.MessageID != receipt.MessageID || got.From != "sender@example.com" || strings.Join(got.To, ",") != "recipient@example.com" || got.Subject != testMail.Subject {
+		t.Errorf("unexpected request %+v", got)
+	}
+	if !bytes.Contains(got.Raw, []byte("Message-ID: "+receipt.MessageID+"\r\n")) {
+		t.Errorf("raw message does not carry Message-ID %s", receipt.MessageID)
+	}
+	if !strings.Contains(receipt.Response, "202") || !strings.Contains(receipt.Response, "queued-1") {
+		t.Errorf("expected the API response in the receipt, got %q", receipt.Response)
+	}
+}
+
+func TestHTTPMailerClassifiesStatus(t *testing.T) {
+	tests := []struct {
+		status        int
+		wantPermanent bool
+	}{
+		{status: http.StatusServiceUnavailable},
+		{status: http.StatusTooManyRequests},
+		{status: http.StatusBadRequest, wantPermanent: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "stand-in failure", tt.status)
+			}))
+			defer server.Close()
+
+			mailer, err := NewHTTPMailer(server.URL, "", time.Minute, NewMessageBuilder("sender@example.com", "test"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = mailer.Send(testMail)
+			expectFailure(t, err, tt.wantPermanent)
+		})
+	}
+}
+
+func TestHTTPMailerTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	mailer, err := NewHTTPMailer(server.URL, "", 200*time.Millisecond, NewMessageBuilder("sender@example.com", "test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = mailer.Send(testMail)
+	expectFailure(t, err, false)
+}
+
+// TestHTTPMailerPostsTrackedHTML checks that html_body carries the same tracking as the
+// raw message, for APIs that build the message themselves.
+func TestHTTPMailerPostsTrackedHTML(t *testing.T) {
+	var got httpRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &got)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	tracker := NewLinkTracker("https://mail.example.com", domain.NewTrackingSigner("secret"), true)
+	mailer, err := NewHTTPMailer(server.URL, "", time.Minute, NewMessageBuilder("sender@example.com", "test").TrackWith(tracker))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = mailer.Send(domain.Mail{
+		ID:       uuid.New(),
+		To:       []string{"recipient@example.com"},
+		Subject:  "test",
+		HTMLBody: `<p><a href="https://example.com/offer">offer</a></p>`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(got.HTMLBody, "https://mail.example.com/t/c/") || !strings.Contains(got.HTMLBody, "https://mail.example.com/t/o/") {
+		t.Errorf("expected tracked links and the pixel in html_body, got %q", got.HTMLBody)
+	}
+	if strings.Contains(got.HTMLBody, `href="https://example.com/offer"`) {
+		t.Error("html_body still links to the untracked URL")
+	}
+}
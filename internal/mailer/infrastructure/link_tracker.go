@@ -0,0 +1,68 @@
+package infrastructure
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	"monolith-domain/internal/mailer/domain"
+
+	"github.com/google/uuid"
+)
+
+// trackedHref matches the href of an anchor pointing to an http(s) URL. Links marked
+// with data-notrack are left alone, as are mailto:, tel: and fragment links.
+var trackedHref = regexp.MustCompile(`(?i)(<a\b[^>]*?\bhref\s*=\s*)("https?://[^"]*"|'https?://[^']*')([^>]*>)`)
+
+// closingBody finds where the tracking pixel is inserted.
+var closingBody = regexp.MustCompile(`(?i)</body\s*>`)
+
+// LinkTracker instruments HTML bodies for open and click tracking: links are rewritten
+// to the click redirect endpoint and a 1x1 pixel pointing to the open endpoint is
+// appended. Both carry a signed token identifying the message.
+type LinkTracker struct {
+	baseURL   string
+	signer    *domain.TrackingSigner
+	byDefault bool
+}
+
+// NewLinkTracker creates a tracker for endpoints served under baseURL. byDefault decides
+// for mails that neither request nor refuse tracking.
+func NewLinkTracker(baseURL string, signer *domain.TrackingSigner, byDefault bool) *LinkTracker {
+	return &LinkTracker{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		signer:    signer,
+		byDefault: byDefault,
+	}
+}
+
+// Tracks reports whether mail is to be tracked. Only HTML mail with a message ID can be.
+func (t *LinkTracker) Tracks(mail domain.Mail) bool {
+	if mail.HTMLBody == "" || mail.ID == uuid.Nil {
+		return false
+	}
+	if mail.Track != nil {
+		return *mail.Track
+	}
+	return t.byDefault
+}
+
+// Instrument returns body with tracked links and the tracking pixel.
+func (t *LinkTracker) Instrument(body string, messageID uuid.UUID) string {
+	body = trackedHref.ReplaceAllStringFunc(body, func(match string) string {
+		if strings.Contains(strings.ToLower(match), "data-notrack") {
+			return match
+		}
+		parts := trackedHref.FindStringSubmatch(match)
+		quoted := parts[2]
+		target := html.UnescapeString(quoted[1 : len(quoted)-1])
+		return fmt.Sprintf(`%s"%s/t/c/%s"%s`, parts[1], t.baseURL, t.signer.LinkToken(messageID, target), parts[3])
+	})
+
+	pixel := fmt.Sprintf(`<img src="%s/t/o/%s.gif" width="1" height="1" alt="" style="display:none;border:0">`, t.baseURL, t.signer.OpenToken(messageID))
+	if loc := closingBody.FindStringIndex(body); loc != nil {
+		return body[:loc[0]] + pixel + body[loc[0]:]
+	}
+	return body + pixel
+}
@@ -0,0 +1,138 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"monolith-domain/internal/mailer/domain"
+	"monolith-domain/pkg/observability"
+
+	"go.uber.org/zap"
+)
+
+// MaildirWatcher polls the new/ directory of a Maildir, e.g. the mailbox bounces to the
+// sender address are delivered to, and hands every message to a handler. Handled
+// messages are moved to cur/ and flagged seen. A message the handler fails on is left
+// in new/ and retried on the next poll, unless it is not a delivery status
+// notification at all.
+type MaildirWatcher struct {
+	dir      string
+	interval time.Duration
+	handle   func(raw []byte) error
+	logger   *zap.Logger
+
+	quit     chan struct{}
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+}
+
+// NewMaildirWatcher creates a watcher for the Maildir at dir; call Start to begin polling.
+func NewMaildirWatcher(dir string, interval time.Duration, handle func(raw []byte) error) (*MaildirWatcher, error) {
+	for _, sub := range []string{"new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o750); err != nil {
+			return nil, err
+		}
+	}
+	return &MaildirWatcher{
+		dir:      dir,
+		interval: interval,
+		handle:   handle,
+		logger:   observability.GetLogger(),
+		quit:     make(chan struct{}),
+	}, nil
+}
+
+// Start launches the polling loop.
+func (w *MaildirWatcher) Start() {
+	w.wg.Add(1)
+	go w.run()
+
+	w.logger.Info("Maildir watcher started",
+		zap.String("dir", w.dir),
+		zap.Duration("poll_interval", w.interval),
+	)
+}
+
+// Stop ends polling and waits for the message being handled, or for ctx to expire.
+func (w *MaildirWatcher) Stop(ctx context.Context) error {
+	w.stopOnce.Do(func() { close(w.quit) })
+
+	done := make(chan struct{})
+	go func() {
+		w.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		w.logger.Info("Maildir watcher stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *MaildirWatcher) run() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		w.scan()
+		select {
+		case <-w.quit:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scan handles every message currently in new/.
+func (w *MaildirWatcher) scan() {
+	entries, err := os.ReadDir(filepath.Join(w.dir, "new"))
+	if err != nil {
+		w.logger.Error("Failed to read maildir", zap.String("dir", w.dir), zap.Error(err))
+		return
+	}
+
+	for _, entry := range entries {
+		select {
+		case <-w.quit:
+			return
+		default:
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		w.process(entry.Name())
+	}
+}
+
+func (w *MaildirWatcher) process(name string) {
+	path := filepath.Join(w.dir, "new", name)
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		w.logger.Error("Failed to read maildir message", zap.String("file", path), zap.Error(err))
+		return
+	}
+
+	if err := w.handle(raw); err != nil {
+		if !errors.Is(err, domain.ErrInvalidDSN) {
+			w.logger.Error("Failed to process maildir message, will retry", zap.String("file", path), zap.Error(err))
+			return
+		}
+		w.logger.Warn("Skipping maildir message", zap.String("file", path), zap.Error(err))
+	}
+
+	// Maildir info suffix: version 2, flag S (seen)
+	seen := filepath.Join(w.dir, "cur", name+":2,S")
+	if err := os.Rename(path, seen); err != nil {
+		w.logger.Error("Failed to move maildir message to cur", zap.String("file", path), zap.Error(err))
+	}
+}
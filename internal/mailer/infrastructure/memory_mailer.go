@@ -0,0 +1,72 @@
+package infrastructure
+
+import (
+	"fmt"
+	"sync"
+
+	"monolith-domain/internal/mailer/domain"
+)
+
+// defaultMemoryMailerLimit is how many messages a MemoryMailer keeps by default.
+const defaultMemoryMailerLimit = 1000
+
+// SentMessage is a message recorded by MemoryMailer.
+type SentMessage struct {
+	Mail    domain.Mail
+	Message *BuiltMessage
+}
+
+// MemoryMailer keeps sent messages in memory. It is meant for tests and local runs;
+// only the most recent messages are kept, so a long run does not exhaust memory.
+type MemoryMailer struct {
+	builder  *MessageBuilder
+	limit    int
+	mu       sync.Mutex
+	messages []SentMessage
+}
+
+// NewMemoryMailer creates an in-memory mailer keeping the last 1000 messages.
+func NewMemoryMailer(builder *MessageBuilder) *MemoryMailer {
+	return &MemoryMailer{builder: builder, limit: defaultMemoryMailerLimit}
+}
+
+// WithLimit sets how many of the most recent messages are kept.
+func (m *MemoryMailer) WithLimit(limit int) *MemoryMailer {
+	if limit > 0 {
+		m.limit = limit
+	}
+	return m
+}
+
+// Send builds the message and records it.
+func (m *MemoryMailer) Send(mail domain.Mail) (*domain.DeliveryReceipt, error) {
+	receipt := &domain.DeliveryReceipt{Transport: "memory"}
+	message, err := m.builder.Build(mail)
+	if err != nil {
+		return receipt, fmt.Errorf("failed to build email: %w", &domain.DeliveryError{Permanent: true, Err: err})
+	}
+	receipt.MessageID = message.MessageID
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, SentMessage{Mail: mail, Message: message})
+	if len(m.messages) > m.limit {
+		// Copy so the dropped messages do not stay reachable through the backing array
+		m.messages = append([]SentMessage(nil), m.messages[len(m.messages)-m.limit:]...)
+	}
+	return receipt, nil
+}
+
+// Messages returns a copy of the messages sent so far.
+func (m *MemoryMailer) Messages() []SentMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]SentMessage(nil), m.messages...)
+}
+
+// Reset forgets all recorded messages.
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = nil
+}
@@ -0,0 +1,43 @@
+package infrastructure
+
+import "testing"
+
+func TestMemoryMailerRecordsBuiltMessage(t *testing.T) {
+	mailer := NewMemoryMailer(NewMessageBuilder("sender@example.com", "test"))
+	receipt, err := mailer.Send(testMail)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	messages := mailer.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(messages))
+	}
+	if messages[0].Message.MessageID != receipt.MessageID {
+		t.Errorf("receipt Message-ID %s does not match the recorded %s", receipt.MessageID, messages[0].Message.MessageID)
+	}
+	mailer.Reset()
+	if len(mailer.Messages()) != 0 {
+		t.Error("messages left after Reset")
+	}
+}
+
+func TestMemoryMailerKeepsLastMessages(t *testing.T) {
+	mailer := NewMemoryMailer(NewMessageBuilder("sender@example.com", "test")).WithLimit(2)
+	var ids []string
+	for i := 0; i < 3; i++ {
+		receipt, err := mailer.Send(testMail)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, receipt.MessageID)
+	}
+
+	messages := mailer.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(messages))
+	}
+	if messages[0].Message.MessageID != ids[1] || messages[1].Message.MessageID != ids[2] {
+		t.Error("expected the oldest message to be dropped")
+	}
+}
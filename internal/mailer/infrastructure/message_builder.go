@@ -0,0 +1,201 @@
+package infrastructure
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	netmail "net/mail"
+	"sort"
+	"strings"
+	"time"
+
+	"monolith-domain/internal/mailer/domain"
+
+	"github.com/google/uuid"
+)
+
+// maxHeaderLineLength is the line length RFC 5322 recommends headers to be folded at.
+const maxHeaderLineLength = 78
+
+// BuiltMessage is a Mail rendered to wire format together with its SMTP envelope.
+type BuiltMessage struct {
+	MessageID  string
+	From       string
+	Recipients []string
+	HTMLBody   string // The HTML body as sent, with tracking when the mail is tracked
+	Data       []byte
+}
+
+// MessageBuilder renders domain.Mail values into RFC 5322 messages: headers in a fixed
+// order and folded, a generated Message-ID and Date, RFC 2047 encoded words for
+// non-ASCII subjects and display names, and quoted-printable text bodies.
+type MessageBuilder struct {
+	from     string
+	fromName string
+	domain   string
+	signer   *DKIMSigner
+	tracker  *LinkTracker
+	now      func() time.Time
+}
+
+// NewMessageBuilder creates a builder for messages sent from the given address.
+// The address domain is used for generated Message-IDs.
+func NewMessageBuilder(from, fromName string) *MessageBuilder {
+	idDomain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 && at < len(from)-1 {
+		idDomain = from[at+1:]
+	}
+	return &MessageBuilder{
+		from:     from,
+		fromName: fromName,
+		domain:   idDomain,
+		now:      time.Now,
+	}
+}
+
+// SignWith makes the builder add a DKIM signature to every message it builds.
+// A nil signer disables signing.
+func (b *MessageBuilder) SignWith(signer *DKIMSigner) *MessageBuilder {
+	b.signer = signer
+	return b
+}
+
+// TrackWith makes the builder instrument HTML bodies for open and click tracking.
+// A nil tracker disables tracking.
+func (b *MessageBuilder) TrackWith(tracker *LinkTracker) *MessageBuilder {
+	b.tracker = tracker
+	return b
+}
+
+// ForPreview returns a copy of the builder that neither signs nor tracks. Previews are
+// served to unauthenticated callers, who must not get content signed with the domain's
+// key or live tracking tokens.
+func (b *MessageBuilder) ForPreview() *MessageBuilder {
+	preview := *b
+	preview.signer = nil
+	preview.tracker = nil
+	return &preview
+}
+
+// Build renders mail. Bcc recipients are part of the envelope only.
+func (b *MessageBuilder) Build(mail domain.Mail) (*BuiltMessage, error) {
+	if b.tracker != nil && b.tracker.Tracks(mail) {
+		mail.HTMLBody = b.tracker.Instrument(mail.HTMLBody, mail.ID)
+	}
+	contentType, transferEncoding, body, err := buildBody(mail)
+	if err != nil {
+		return nil, err
+	}
+
+	fromName := b.fromName
+	if mail.FromName != "" {
+		fromName = mail.FromName
+	}
+	id := mail.ID
+	if id == uuid.Nil {
+		id = uuid.New()
+	}
+	messageID := fmt.Sprintf("<%s@%s>", id.String(), b.domain)
+
+	var buf bytes.Buffer
+	writeHeader(&buf, "Date", b.now().Format(time.RFC1123Z))
+	writeHeader(&buf, "From", (&netmail.Address{Name: fromName, Address: b.from}).String())
+	if len(mail.To) > 0 {
+		writeHeader(&buf, "To", formatAddressList(mail.To))
+	}
+	if len(mail.Cc) > 0 {
+		writeHeader(&buf, "Cc", formatAddressList(mail.Cc))
+	}
+	if mail.ReplyTo != "" {
+		writeHeader(&buf, "Reply-To", formatAddressList([]string{mail.ReplyTo}))
+	}
+	writeHeader(&buf, "Subject", mime.QEncoding.Encode("utf-8", mail.Subject))
+	writeHeader(&buf, "Message-ID", messageID)
+
+	names := make([]string, 0, len(mail.Headers))
+	for name := range mail.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeHeader(&buf, name, mime.QEncoding.Encode("utf-8", mail.Headers[name]))
+	}
+
+	writeHeader(&buf, "MIME-Version", "1.0")
+	writeHeader(&buf, "Content-Type", contentType)
+	if transferEncoding != "" {
+		writeHeader(&buf, "Content-Transfer-Encoding", transferEncoding)
+	}
+	buf.WriteString("\r\n")
+	buf.Write(body)
+
+	data := buf.Bytes()
+	if b.signer != nil {
+		if data, err = b.signer.Sign(data); err != nil {
+			return nil, fmt.Errorf("failed to sign email: %w", err)
+		}
+	}
+
+	recipients := mail.Recipients()
+	for i, recipient := range recipients {
+		recipients[i] = envelopeAddress(recipient)
+	}
+
+	return &BuiltMessage{
+		MessageID:  messageID,
+		From:       b.from,
+		Recipients: recipients,
+		HTMLBody:   mail.HTMLBody,
+		Data:       data,
+	}, nil
+}
+
+// Render returns the wire format of mail, for previews.
+func (b *MessageBuilder) Render(mail domain.Mail) ([]byte, error) {
+	built, err := b.Build(mail)
+	if err != nil {
+		return nil, err
+	}
+	return built.Data, nil
+}
+
+// formatAddressList renders addresses with RFC 2047 encoded display names. Values that
+// do not parse as an address are written unchanged.
+func formatAddressList(addresses []string) string {
+	formatted := make([]string, len(addresses))
+	for i, address := range addresses {
+		if parsed, err := netmail.ParseAddress(address); err == nil {
+			formatted[i] = parsed.String()
+		} else {
+			formatted[i] = address
+		}
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// envelopeAddress strips the display name, leaving the bare address for RCPT TO.
+func envelopeAddress(address string) string {
+	if parsed, err := netmail.ParseAddress(address); err == nil {
+		return parsed.Address
+	}
+	return address
+}
+
+// writeHeader writes "Name: value", folding long values at spaces so lines stay
+// within maxHeaderLineLength where the value allows it.
+func writeHeader(buf *bytes.Buffer, name, value string) {
+	line := name + ":"
+	if len(line)+1+len(value) <= maxHeaderLineLength {
+		buf.WriteString(line + " " + value + "\r\n")
+		return
+	}
+
+	for i, word := range strings.Split(value, " ") {
+		if i > 0 && len(line)+1+len(word) > maxHeaderLineLength && strings.TrimSpace(line) != "" {
+			buf.WriteString(line + "\r\n")
+			line = ""
+		}
+		line += " " + word
+	}
+	buf.WriteString(line + "\r\n")
+}
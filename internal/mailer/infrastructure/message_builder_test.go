@@ -0,0 +1,51 @@
+package infrastructure
+
+import (
+	"bytes"
+	"testing"
+
+	"monolith-domain/internal/mailer/domain"
+
+	"github.com/google/uuid"
+)
+
+// TestMessageBuilderForPreview renders the same mail with a signing and tracking builder
+// and with its preview copy, which must carry neither a signature nor tracking.
+func TestMessageBuilderForPreview(t *testing.T) {
+	signer, _ := generateDKIMSigner(t, "ed25519")
+	tracker := NewLinkTracker("https://mail.example.com", domain.NewTrackingSigner("secret"), true)
+	builder := NewMessageBuilder("sender@example.com", "test").SignWith(signer).TrackWith(tracker)
+	mail := domain.Mail{
+		ID:       uuid.New(),
+		To:       []string{"recipient@example.com"},
+		Subject:  "test",
+		HTMLBody: `<p><a href="https://example.com/offer">offer</a></p>`,
+	}
+
+	sent, err := builder.Render(mail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(sent, []byte("DKIM-Signature: ")) || !bytes.Contains(sent, []byte("/t/o/")) {
+		t.Fatal("expected the sending builder to sign and track")
+	}
+
+	preview, err := builder.ForPreview().Render(mail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(preview, []byte("DKIM-Signature")) {
+		t.Error("preview is DKIM-signed")
+	}
+	if bytes.Contains(preview, []byte("/t/o/")) || bytes.Contains(preview, []byte("/t/c/")) {
+		t.Error("preview carries tracking URLs")
+	}
+
+	again, err := builder.Render(mail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(again, []byte("DKIM-Signature: ")) {
+		t.Error("ForPreview changed the original builder")
+	}
+}
@@ -0,0 +1,174 @@
+package infrastructure
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"path/filepath"
+
+	"monolith-domain/internal/mailer/domain"
+)
+
+// base64LineLength is the maximum encoded line length allowed by RFC 2045.
+const base64LineLength = 76
+
+// mimePart is a single entity of a MIME tree: its headers and already encoded body.
+type mimePart struct {
+	header textproto.MIMEHeader
+	body   []byte
+}
+
+// textPart encodes body as quoted-printable, which keeps lines within 76 characters
+// and makes non-ASCII text safe for servers without 8BITMIME.
+func textPart(contentType, body string) mimePart {
+	var buf bytes.Buffer
+	writer := quotedprintable.NewWriter(&buf)
+	writer.Write([]byte(body))
+	writer.Close()
+
+	return mimePart{
+		header: textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		},
+		body: buf.Bytes(),
+	}
+}
+
+// buildBody returns the top-level Content-Type, Content-Transfer-Encoding (empty for
+// multipart) and the encoded body of mail.
+// The parts are nested as
+//
+//	multipart/mixed               when there are regular attachments
+//	└─ multipart/alternative      when there is both a text and an HTML body
+//	   ├─ text/plain
+//	   └─ multipart/related       when the HTML body has inline images
+//	      ├─ text/html
+//	      └─ inline images
+//
+// and every level that would hold a single part is collapsed into that part.
+func buildBody(mail domain.Mail) (string, string, []byte, error) {
+	var inline, attached []domain.Attachment
+	for _, attachment := range mail.Attachments {
+		if attachment.IsInline() && mail.HTMLBody != "" {
+			inline = append(inline, attachment)
+		} else {
+			attached = append(attached, attachment)
+		}
+	}
+
+	var alternatives []mimePart
+	if mail.TextBody != "" || mail.HTMLBody == "" {
+		alternatives = append(alternatives, textPart("text/plain; charset=UTF-8", mail.TextBody))
+	}
+	if mail.HTMLBody != "" {
+		html := textPart("text/html; charset=UTF-8", mail.HTMLBody)
+		if len(inline) > 0 {
+			parts := []mimePart{html}
+			for _, attachment := range inline {
+				parts = append(parts, attachmentPart(attachment))
+			}
+			var err error
+			if html, err = multipartPart("multipart/related", parts); err != nil {
+				return "", "", nil, err
+			}
+		}
+		alternatives = append(alternatives, html)
+	}
+
+	content := alternatives[0]
+	if len(alternatives) > 1 {
+		var err error
+		if content, err = multipartPart("multipart/alternative", alternatives); err != nil {
+			return "", "", nil, err
+		}
+	}
+
+	if len(attached) > 0 {
+		parts := []mimePart{content}
+		for _, attachment := range attached {
+			parts = append(parts, attachmentPart(attachment))
+		}
+		var err error
+		if content, err = multipartPart("multipart/mixed", parts); err != nil {
+			return "", "", nil, err
+		}
+	}
+
+	return content.header.Get("Content-Type"), content.header.Get("Content-Transfer-Encoding"), content.body, nil
+}
+
+// multipartPart joins parts into a single multipart entity of mediaType.
+func multipartPart(mediaType string, parts []mimePart) (mimePart, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	for _, part := range parts {
+		w, err := writer.CreatePart(part.header)
+		if err != nil {
+			return mimePart{}, err
+		}
+		if _, err := w.Write(part.body); err != nil {
+			return mimePart{}, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return mimePart{}, err
+	}
+
+	params := map[string]string{"boundary": writer.Boundary()}
+	if mediaType == "multipart/related" {
+		// RFC 2387 requires the type of the root part
+		rootType, _, _ := mime.ParseMediaType(parts[0].header.Get("Content-Type"))
+		params["type"] = rootType
+	}
+
+	return mimePart{
+		header: textproto.MIMEHeader{"Content-Type": {mime.FormatMediaType(mediaType, params)}},
+		body:   buf.Bytes(),
+	}, nil
+}
+
+func attachmentPart(attachment domain.Attachment) mimePart {
+	contentType := attachment.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(attachment.Filename))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	disposition := "attachment"
+	if attachment.IsInline() {
+		disposition = "inline"
+	}
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Transfer-Encoding", "base64")
+	header.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename}))
+	if attachment.IsInline() {
+		header.Set("Content-ID", "<"+attachment.ContentID+">")
+	}
+
+	var body bytes.Buffer
+	writeBase64(&body, attachment.Content)
+	return mimePart{header: header, body: body.Bytes()}
+}
+
+// writeBase64 encodes data wrapped at base64LineLength characters per line.
+func writeBase64(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 0 {
+		n := min(base64LineLength, len(encoded))
+		if _, err := io.WriteString(w, encoded[:n]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package infrastructure
+
+import (
+	"time"
+
+	"monolith-domain/internal/mailer/domain"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PostgresDeliveryLogRepository stores the delivery log in the mail_delivery_log table.
+type PostgresDeliveryLogRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresDeliveryLogRepository(db *gorm.DB) *PostgresDeliveryLogRepository {
+	return &PostgresDeliveryLogRepository{db: db}
+}
+
+func (r *PostgresDeliveryLogRepository) Record(entries ...*domain.DeliveryLogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return r.db.Create(entries).Error
+}
+
+// Update applies the outcome of a delivery attempt to every recipient of the message.
+func (r *PostgresDeliveryLogRepository) Update(messageID uuid.UUID, update domain.DeliveryUpdate) error {
+	return r.db.Model(&domain.DeliveryLogEntry{}).Where("message_id = ?", messageID).Updates(deliveryUpdateFields(update)).Error
+}
+
+func (r *PostgresDeliveryLogRepository) UpdateRecipient(messageID uuid.UUID, recipient string, update domain.DeliveryUpdate) error {
+	return r.db.Model(&domain.DeliveryLogEntry{}).
+		Where("message_id = ? AND recipient = ?", messageID, domain.NormalizeRecipient(recipient)).
+		Updates(deliveryUpdateFields(update)).Error
+}
+
+func (r *PostgresDeliveryLogRepository) Reassign(messageID uuid.UUID, recipient string, newMessageID uuid.UUID, update domain.DeliveryUpdate) error {
+	fields := deliveryUpdateFields(update)
+	fields["message_id"] = newMessageID
+	return r.db.Model(&domain.DeliveryLogEntry{}).
+		Where("message_id = ? AND recipient = ?", messageID, domain.NormalizeRecipient(recipient)).
+		Updates(fields).Error
+}
+
+func deliveryUpdateFields(update domain.DeliveryUpdate) map[string]interface{} {
+	fields := map[string]interface{}{
+		"status":   update.Status,
+		"attempts": update.Attempts,
+		"response": update.Response,
+	}
+	if update.Transport != "" {
+		fields["transport"] = update.Transport
+	}
+	if !update.At.IsZero() {
+		fields["last_attempt_at"] = update.At
+	}
+	if update.Status == domain.DeliveryStatusSent {
+		fields["sent_at"] = update.At
+	}
+	return fields
+}
+
+func (r *PostgresDeliveryLogRepository) MarkBounced(messageID uuid.UUID, recipient, response string) (bool, error) {
+	result := r.db.Model(&domain.DeliveryLogEntry{}).
+		Where("message_id = ? AND recipient = ?", messageID, domain.NormalizeRecipient(recipient)).
+		Updates(map[string]interface{}{
+			"status":   domain.DeliveryStatusBounced,
+			"response": response,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *PostgresDeliveryLogRepository) RecordEngagement(messageID uuid.UUID, eventType domain.TrackingEventType, at time.Time) error {
+	counter, first := "opens", "opened_at"
+	if eventType == domain.TrackingEventClick {
+		counter, first = "clicks", "clicked_at"
+	}
+	return r.db.Model(&domain.DeliveryLogEntry{}).Where("message_id = ?", messageID).Updates(map[string]interface{}{
+		counter: gorm.Expr(counter + " + 1"),
+		first:   gorm.Expr("COALESCE("+first+", ?)", at),
+	}).Error
+}
+
+func (r *PostgresDeliveryLogRepository) Search(filter domain.DeliveryLogFilter) ([]*domain.DeliveryLogEntry, int64, error) {
+	var entries []*domain.DeliveryLogEntry
+	var total int64
+
+	if err := r.filtered(filter).Model(&domain.DeliveryLogEntry{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (filter.Page - 1) * filter.Size
+	err := r.filtered(filter).
+		Order("queued_at DESC").
+		Offset(offset).
+		Limit(filter.Size).
+		Find(&entries).Error
+
+	return entries, total, err
+}
+
+// filtered returns a fresh query with the filter's conditions, so the count and the
+// page query do not share (and mutate) one statement.
+func (r *PostgresDeliveryLogRepository) filtered(filter domain.DeliveryLogFilter) *gorm.DB {
+	query := r.db
+	if filter.Recipient != "" {
+		query = query.Where("recipient = ?", domain.NormalizeRecipient(filter.Recipient))
+	}
+	if filter.MessageID != uuid.Nil {
+		query = query.Where("message_id = ?", filter.MessageID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Campaign != "" {
+		query = query.Where("campaign = ?", filter.Campaign)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("queued_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("queued_at < ?", filter.To)
+	}
+	return query
+}
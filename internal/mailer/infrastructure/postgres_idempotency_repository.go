@@ -0,0 +1,95 @@
+package infrastructure
+
+import (
+	"errors"
+	"time"
+
+	"monolith-domain/internal/mailer/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostgresIdempotencyRepository stores idempotency keys in the mail_idempotency_keys table.
+type PostgresIdempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresIdempotencyRepository(db *gorm.DB) *PostgresIdempotencyRepository {
+	return &PostgresIdempotencyRepository{db: db}
+}
+
+// Reserve relies on the primary key so two concurrent requests with the same key
+// cannot both reserve it. A conflicting row is only overwritten once it has expired.
+func (r *PostgresIdempotencyRepository) Reserve(record *domain.IdempotencyRecord) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}, {Name: "scope"}},
+		DoUpdates: clause.AssignmentColumns([]string{"request_hash", "status_code", "response", "created_at", "expires_at"}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{SQL: "mail_idempotency_keys.expires_at <= ?", Vars: []interface{}{record.CreatedAt}},
+		}},
+	}).Create(record)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *PostgresIdempotencyRepository) Find(scope, key string) (*domain.IdempotencyRecord, error) {
+	var record domain.IdempotencyRecord
+	err := r.db.Where("scope = ? AND key = ?", scope, key).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *PostgresIdempotencyRepository) Complete(record *domain.IdempotencyRecord) error {
+	result := r.reservation(record).
+		Model(&domain.IdempotencyRecord{}).
+		Updates(map[string]interface{}{
+			"status_code": record.StatusCode,
+			"response":    record.Response,
+			"expires_at":  record.ExpiresAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrIdempotencyReservationLost
+	}
+	return nil
+}
+
+func (r *PostgresIdempotencyRepository) Extend(record *domain.IdempotencyRecord, until time.Time) error {
+	result := r.reservation(record).Model(&domain.IdempotencyRecord{}).Update("expires_at", until)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrIdempotencyReservationLost
+	}
+	return nil
+}
+
+func (r *PostgresIdempotencyRepository) Delete(record *domain.IdempotencyRecord) error {
+	result := r.reservation(record).Delete(&domain.IdempotencyRecord{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrIdempotencyReservationLost
+	}
+	return nil
+}
+
+// reservation scopes a query to the in-progress row created for record, so a request
+// whose lock expired cannot overwrite or free the reservation of the retry that took over.
+func (r *PostgresIdempotencyRepository) reservation(record *domain.IdempotencyRecord) *gorm.DB {
+	return r.db.Where("scope = ? AND key = ? AND status_code = 0 AND created_at = ?", record.Scope, record.Key, record.CreatedAt)
+}
+
+func (r *PostgresIdempotencyRepository) DeleteExpired(before time.Time) (int64, error) {
+	result := r.db.Where("expires_at <= ?", before).Delete(&domain.IdempotencyRecord{})
+	return result.RowsAffected, result.Error
+}
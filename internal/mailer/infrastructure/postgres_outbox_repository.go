@@ -0,0 +1,213 @@
+package infrastructure
+
+import (
+	"errors"
+	"time"
+
+	"monolith-domain/internal/mailer/domain"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PostgresOutboxRepository stores queued mail in the mail_outbox table.
+type PostgresOutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresOutboxRepository(db *gorm.DB) *PostgresOutboxRepository {
+	return &PostgresOutboxRepository{db: db}
+}
+
+func (r *PostgresOutboxRepository) Enqueue(messages ...*domain.OutboxMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	return r.db.Create(messages).Error
+}
+
+// ClaimPending uses FOR UPDATE SKIP LOCKED so several workers (or instances) can
+// drain the table concurrently without picking up the same row twice.
+func (r *PostgresOutboxRepository) ClaimPending(limit int, lockTimeout time.Duration) ([]*domain.OutboxMessage, error) {
+	var messages []*domain.OutboxMessage
+	now := time.Now().Truncate(time.Microsecond)
+	err := r.db.Raw(`
+		UPDATE mail_outbox
+		SET status = ?, locked_at = ?, attempts = attempts + 1, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM mail_outbox
+			WHERE (status IN (?, ?) AND next_attempt_at <= ?) OR (status = ? AND locked_at < ?)
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		domain.OutboxStatusProcessing, now, now,
+		domain.OutboxStatusPending, domain.OutboxStatusScheduled, now, domain.OutboxStatusProcessing, now.Add(-lockTimeout),
+		limit,
+	).Scan(&messages).Error
+	return messages, err
+}
+
+func (r *PostgresOutboxRepository) MarkSent(message *domain.OutboxMessage) error {
+	now := time.Now()
+	return r.updateClaimed(message, map[string]interface{}{
+		"status":     domain.OutboxStatusSent,
+		"sent_at":    now,
+		"locked_at":  nil,
+		"last_error": "",
+	})
+}
+
+func (r *PostgresOutboxRepository) MarkRetry(message *domain.OutboxMessage, reason string, nextAttemptAt time.Time) error {
+	return r.updateClaimed(message, map[string]interface{}{
+		"status":          domain.OutboxStatusPending,
+		"locked_at":       nil,
+		"last_error":      reason,
+		"next_attempt_at": nextAttemptAt,
+	})
+}
+
+func (r *PostgresOutboxRepository) MarkDead(message *domain.OutboxMessage, reason string) error {
+	return r.updateClaimed(message, map[string]interface{}{
+		"status":     domain.OutboxStatusDead,
+		"locked_at":  nil,
+		"last_error": reason,
+	})
+}
+
+func (r *PostgresOutboxRepository) Relock(message *domain.OutboxMessage) error {
+	now := time.Now().Truncate(time.Microsecond)
+	if err := r.updateClaimed(message, map[string]interface{}{"locked_at": now}); err != nil {
+		return err
+	}
+	message.LockedAt = &now
+	return nil
+}
+
+func (r *PostgresOutboxRepository) Defer(message *domain.OutboxMessage, until time.Time) error {
+	return r.updateClaimed(message, map[string]interface{}{
+		"status":          domain.OutboxStatusPending,
+		"locked_at":       nil,
+		"attempts":        gorm.Expr("GREATEST(attempts - 1, 0)"),
+		"next_attempt_at": until,
+	})
+}
+
+// updateClaimed changes a message only while it is still processing under the claim the
+// caller holds. A worker whose lock expired and was reclaimed matches no row, so it
+// cannot overwrite the outcome recorded by the worker that took over.
+func (r *PostgresOutboxRepository) updateClaimed(message *domain.OutboxMessage, fields map[string]interface{}) error {
+	if message.LockedAt == nil {
+		return domain.ErrClaimLost
+	}
+	result := r.db.Model(&domain.OutboxMessage{}).
+		Where("id = ? AND status = ? AND locked_at = ?", message.ID, domain.OutboxStatusProcessing, *message.LockedAt).
+		Updates(fields)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrClaimLost
+	}
+	return nil
+}
+
+func (r *PostgresOutboxRepository) Requeue(id uuid.UUID) error {
+	result := r.db.Model(&domain.OutboxMessage{}).
+		Where("id = ? AND status = ?", id, domain.OutboxStatusDead).
+		Updates(map[string]interface{}{
+			"status":          domain.OutboxStatusPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.FindByID(id); err != nil {
+			return err
+		}
+		return domain.ErrMessageNotDead
+	}
+	return nil
+}
+
+func (r *PostgresOutboxRepository) FindByID(id uuid.UUID) (*domain.OutboxMessage, error) {
+	var message domain.OutboxMessage
+	err := r.db.First(&message, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrMessageNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+func (r *PostgresOutboxRepository) FindAllDead(page, size int) ([]*domain.OutboxMessage, int64, error) {
+	var messages []*domain.OutboxMessage
+	var total int64
+
+	if err := r.db.Model(&domain.OutboxMessage{}).Where("status = ?", domain.OutboxStatusDead).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * size
+	err := r.db.Where("status = ?", domain.OutboxStatusDead).
+		Order("updated_at DESC").
+		Offset(offset).
+		Limit(size).
+		Find(&messages).Error
+
+	return messages, total, err
+}
+
+func (r *PostgresOutboxRepository) FindAllScheduled(page, size int) ([]*domain.OutboxMessage, int64, error) {
+	var messages []*domain.OutboxMessage
+	var total int64
+
+	if err := r.db.Model(&domain.OutboxMessage{}).Where("status = ?", domain.OutboxStatusScheduled).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * size
+	err := r.db.Where("status = ?", domain.OutboxStatusScheduled).
+		Order("next_attempt_at").
+		Offset(offset).
+		Limit(size).
+		Find(&messages).Error
+
+	return messages, total, err
+}
+
+func (r *PostgresOutboxRepository) Reschedule(id uuid.UUID, at time.Time) error {
+	return r.updateScheduled(id, map[string]interface{}{
+		"scheduled_at":    at,
+		"next_attempt_at": at,
+	})
+}
+
+func (r *PostgresOutboxRepository) Cancel(id uuid.UUID) error {
+	return r.updateScheduled(id, map[string]interface{}{
+		"status": domain.OutboxStatusCancelled,
+	})
+}
+
+// updateScheduled changes a message only while it is scheduled. The status check is part
+// of the UPDATE, so a message the dispatcher claims concurrently is left alone.
+func (r *PostgresOutboxRepository) updateScheduled(id uuid.UUID, fields map[string]interface{}) error {
+	result := r.db.Model(&domain.OutboxMessage{}).
+		Where("id = ? AND status = ?", id, domain.OutboxStatusScheduled).
+		Updates(fields)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.FindByID(id); err != nil {
+			return err
+		}
+		return domain.ErrMessageNotScheduled
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package infrastructure
+
+import (
+	"errors"
+
+	"monolith-domain/internal/mailer/domain"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostgresSuppressionRepository stores the suppression list in the mail_suppressions table.
+type PostgresSuppressionRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresSuppressionRepository(db *gorm.DB) *PostgresSuppressionRepository {
+	return &PostgresSuppressionRepository{db: db}
+}
+
+func (r *PostgresSuppressionRepository) Create(suppression *domain.Suppression) error {
+	created, err := r.insert(suppression)
+	if err != nil {
+		return err
+	}
+	if !created {
+		return domain.ErrSuppressionExists
+	}
+	return nil
+}
+
+func (r *PostgresSuppressionRepository) CreateIfMissing(suppression *domain.Suppression) error {
+	_, err := r.insert(suppression)
+	return err
+}
+
+// insert relies on the unique email index so concurrent inserts of the same address
+// cannot race; it reports whether a row was written.
+func (r *PostgresSuppressionRepository) insert(suppression *domain.Suppression) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "email"}}, DoNothing: true}).Create(suppression)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *PostgresSuppressionRepository) Update(suppression *domain.Suppression) error {
+	return r.db.Save(suppression).Error
+}
+
+func (r *PostgresSuppressionRepository) Delete(id uuid.UUID) error {
+	result := r.db.Delete(&domain.Suppression{}, "id = ?", id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.ErrSuppressionNotFound
+	}
+	return nil
+}
+
+func (r *PostgresSuppressionRepository) FindByID(id uuid.UUID) (*domain.Suppression, error) {
+	var suppression domain.Suppression
+	err := r.db.First(&suppression, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrSuppressionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &suppression, nil
+}
+
+func (r *PostgresSuppressionRepository) FindByEmails(emails []string) ([]*domain.Suppression, error) {
+	var suppressions []*domain.Suppression
+	if len(emails) == 0 {
+		return suppressions, nil
+	}
+	err := r.db.Where("email IN ?", emails).Find(&suppressions).Error
+	return suppressions, err
+}
+
+func (r *PostgresSuppressionRepository) FindAll(reason domain.SuppressionReason, page, size int) ([]*domain.Suppression, int64, error) {
+	var suppressions []*domain.Suppression
+	var total int64
+
+	query := func() *gorm.DB {
+		if reason != "" {
+			return r.db.Where("reason = ?", reason)
+		}
+		return r.db
+	}
+
+	if err := query().Model(&domain.Suppression{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * size
+	err := query().
+		Order("created_at DESC").
+		Offset(offset).
+		Limit(size).
+		Find(&suppressions).Error
+
+	return suppressions, total, err
+}
@@ -0,0 +1,72 @@
+package infrastructure
+
+import (
+	"errors"
+
+	"monolith-domain/internal/mailer/domain"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// PostgresTemplateRepository stores email templates in the email_templates table.
+type PostgresTemplateRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresTemplateRepository(db *gorm.DB) *PostgresTemplateRepository {
+	return &PostgresTemplateRepository{db: db}
+}
+
+func (r *PostgresTemplateRepository) Create(template *domain.EmailTemplate) error {
+	return r.db.Create(template).Error
+}
+
+func (r *PostgresTemplateRepository) Update(template *domain.EmailTemplate) error {
+	return r.db.Save(template).Error
+}
+
+func (r *PostgresTemplateRepository) Delete(id uuid.UUID) error {
+	return r.db.Delete(&domain.EmailTemplate{}, id).Error
+}
+
+func (r *PostgresTemplateRepository) FindByID(id uuid.UUID) (*domain.EmailTemplate, error) {
+	var template domain.EmailTemplate
+	err := r.db.First(&template, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *PostgresTemplateRepository) FindByName(name string) (*domain.EmailTemplate, error) {
+	var template domain.EmailTemplate
+	err := r.db.Where("name = ?", name).First(&template).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrTemplateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+func (r *PostgresTemplateRepository) FindAll(page, size int) ([]*domain.EmailTemplate, int64, error) {
+	var templates []*domain.EmailTemplate
+	var total int64
+
+	if err := r.db.Model(&domain.EmailTemplate{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * size
+	err := r.db.Order("name").
+		Offset(offset).
+		Limit(size).
+		Find(&templates).Error
+
+	return templates, total, err
+}
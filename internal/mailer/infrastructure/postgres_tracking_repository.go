@@ -0,0 +1,102 @@
+package infrastructure
+
+import (
+	"monolith-domain/internal/mailer/domain"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxLinkStats bounds the per-link breakdown of the tracking statistics.
+const maxLinkStats = 20
+
+// PostgresTrackingRepository stores opens and clicks in the mail_tracking_events table.
+type PostgresTrackingRepository struct {
+	db *gorm.DB
+}
+
+func NewPostgresTrackingRepository(db *gorm.DB) *PostgresTrackingRepository {
+	return &PostgresTrackingRepository{db: db}
+}
+
+func (r *PostgresTrackingRepository) Record(event *domain.TrackingEvent) error {
+	return r.db.Create(event).Error
+}
+
+// Stats counts recipients from the delivery log and events from the tracking table,
+// both restricted to the messages matching filter.
+func (r *PostgresTrackingRepository) Stats(filter domain.TrackingFilter) (*domain.TrackingStats, error) {
+	stats := &domain.TrackingStats{Links: []domain.LinkStats{}}
+
+	var recipients struct {
+		Recipients int64
+		Opened     int64
+		Clicked    int64
+	}
+	err := r.entries(filter).
+		Select("COUNT(*) AS recipients, COUNT(opened_at) AS opened, COUNT(clicked_at) AS clicked").
+		Scan(&recipients).Error
+	if err != nil {
+		return nil, err
+	}
+	stats.Recipients, stats.Opened, stats.Clicked = recipients.Recipients, recipients.Opened, recipients.Clicked
+
+	var counts []struct {
+		Type  domain.TrackingEventType
+		Count int64
+	}
+	err = r.events(filter).
+		Select("type, COUNT(*) AS count").
+		Group("type").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, count := range counts {
+		switch count.Type {
+		case domain.TrackingEventOpen:
+			stats.Opens = count.Count
+		case domain.TrackingEventClick:
+			stats.Clicks = count.Count
+		}
+	}
+
+	err = r.events(filter).
+		Select("url, COUNT(*) AS clicks").
+		Where("type = ?", domain.TrackingEventClick).
+		Group("url").
+		Order("clicks DESC").
+		Limit(maxLinkStats).
+		Scan(&stats.Links).Error
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// entries returns the delivery log entries matching filter.
+func (r *PostgresTrackingRepository) entries(filter domain.TrackingFilter) *gorm.DB {
+	query := r.db.Model(&domain.DeliveryLogEntry{})
+	if filter.MessageID != uuid.Nil {
+		query = query.Where("message_id = ?", filter.MessageID)
+	}
+	if filter.Campaign != "" {
+		query = query.Where("campaign = ?", filter.Campaign)
+	}
+	if filter.Template != "" {
+		query = query.Where("template = ?", filter.Template)
+	}
+	return query
+}
+
+// events returns the tracking events of the messages matching filter.
+func (r *PostgresTrackingRepository) events(filter domain.TrackingFilter) *gorm.DB {
+	query := r.db.Model(&domain.TrackingEvent{})
+	if filter.MessageID != uuid.Nil {
+		return query.Where("message_id = ?", filter.MessageID)
+	}
+	if filter.Campaign == "" && filter.Template == "" {
+		return query
+	}
+	return query.Where("message_id IN (?)", r.entries(filter).Distinct("message_id"))
+}
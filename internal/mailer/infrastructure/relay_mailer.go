@@ -0,0 +1,255 @@
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"time"
+
+	"monolith-domain/internal/mailer/domain"
+	"monolith-domain/pkg/observability"
+
+	"go.uber.org/zap"
+)
+
+var errNoHealthyRelay = errors.New("no healthy mail relay available")
+
+// Relay is one delivery route of a RelayMailer.
+type Relay struct {
+	Name     string
+	Priority int // lower values are tried first
+	Weight   int // traffic share among relays with the same priority
+	Mailer   domain.MailerRepository
+}
+
+// relayProber is implemented by mailers that can check their relay without sending mail.
+type relayProber interface {
+	Probe() error
+}
+
+type relayState struct {
+	Relay
+	failures  int
+	down      bool
+	nextProbe time.Time
+}
+
+// relayCandidate is a relay picked for one delivery; probe is set when the relay is
+// down and due to be checked before it is used again.
+type relayCandidate struct {
+	state *relayState
+	probe bool
+}
+
+// RelayMailer routes mail over several relays. Relays are tried by priority, spreading
+// load by weight within a priority, and the next one is used when a relay fails
+// transiently. After failureThreshold consecutive failures a relay is marked down and
+// skipped until probeInterval has passed, when it is probed and brought back if healthy.
+type RelayMailer struct {
+	relays           []*relayState
+	failureThreshold int
+	probeInterval    time.Duration
+	mu               sync.Mutex
+	now              func() time.Time
+	logger           *zap.Logger
+}
+
+// NewRelayMailer creates a mailer routing over relays.
+func NewRelayMailer(relays []Relay, failureThreshold int, probeInterval time.Duration) (*RelayMailer, error) {
+	if len(relays) == 0 {
+		return nil, errors.New("at least one mail relay is required")
+	}
+	if failureThreshold <= 0 {
+		failureThreshold = 1
+	}
+
+	names := make(map[string]bool)
+	states := make([]*relayState, 0, len(relays))
+	for _, relay := range relays {
+		if relay.Name == "" || names[relay.Name] {
+			return nil, fmt.Errorf("mail relay names must be unique and non-empty, got %q", relay.Name)
+		}
+		names[relay.Name] = true
+		if relay.Weight <= 0 {
+			relay.Weight = 1
+		}
+		states = append(states, &relayState{Relay: relay})
+		observability.SetRelayUp(relay.Name, true)
+	}
+
+	return &RelayMailer{
+		relays:           states,
+		failureThreshold: failureThreshold,
+		probeInterval:    probeInterval,
+		now:              time.Now,
+		logger:           observability.GetLogger(),
+	}, nil
+}
+
+// Send delivers mail through the first relay that accepts it. A rejection of a
+// recipient or of the content, permanent or deferred, is returned at once and leaves
+// the relay healthy: the relay answered, and the message is retried or dead-lettered
+// like with a single transport. Failures of the session itself (connection, TLS, a
+// refused login or sender, a 421) count against the relay and the next one is tried.
+func (m *RelayMailer) Send(mail domain.Mail) (*domain.DeliveryReceipt, error) {
+	candidates := m.candidates()
+	if len(candidates) == 0 {
+		return nil, &domain.DeliveryError{Err: errNoHealthyRelay}
+	}
+
+	var lastReceipt *domain.DeliveryReceipt
+	lastErr := error(&domain.DeliveryError{Err: errNoHealthyRelay})
+	for _, candidate := range candidates {
+		relay := candidate.state
+		if candidate.probe && !m.probe(relay) {
+			continue
+		}
+
+		receipt, err := relay.Mailer.Send(mail)
+		receipt = relayReceipt(relay.Name, receipt)
+		observability.RecordRelayDelivery(relay.Name, err == nil)
+		if err == nil || domain.IsMessageRejected(err) || domain.IsMessageDeferred(err) {
+			// The relay answered, so it is healthy even if it refused this message
+			m.markHealthy(relay)
+			return receipt, err
+		}
+
+		m.markFailed(relay, err)
+		lastReceipt, lastErr = receipt, err
+	}
+
+	return lastReceipt, fmt.Errorf("all mail relays failed: %w", lastErr)
+}
+
+// relayReceipt prefixes the transport with the relay name, e.g. "backup (smtp://host:587)".
+func relayReceipt(name string, receipt *domain.DeliveryReceipt) *domain.DeliveryReceipt {
+	if receipt == nil {
+		return &domain.DeliveryReceipt{Transport: name}
+	}
+	named := *receipt
+	named.Transport = fmt.Sprintf("%s (%s)", name, receipt.Transport)
+	return &named
+}
+
+// candidates returns the usable relays in the order they should be tried. Down
+// relays due for a probe are included; their next probe is pushed back right away
+// so concurrent sends do not all probe the same relay.
+func (m *RelayMailer) candidates() []relayCandidate {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	byPriority := make(map[int][]relayCandidate)
+	var priorities []int
+	for _, relay := range m.relays {
+		candidate := relayCandidate{state: relay}
+		if relay.down {
+			if now.Before(relay.nextProbe) {
+				continue
+			}
+			relay.nextProbe = now.Add(m.probeInterval)
+			candidate.probe = true
+		}
+		if _, ok := byPriority[relay.Priority]; !ok {
+			priorities = append(priorities, relay.Priority)
+		}
+		byPriority[relay.Priority] = append(byPriority[relay.Priority], candidate)
+	}
+	sort.Ints(priorities)
+
+	var ordered []relayCandidate
+	for _, priority := range priorities {
+		ordered = append(ordered, weightedShuffle(byPriority[priority])...)
+	}
+	return ordered
+}
+
+// weightedShuffle orders candidates randomly, favouring higher weights for earlier slots.
+func weightedShuffle(candidates []relayCandidate) []relayCandidate {
+	remaining := append([]relayCandidate(nil), candidates...)
+	ordered := make([]relayCandidate, 0, len(candidates))
+	for len(remaining) > 0 {
+		total := 0
+		for _, candidate := range remaining {
+			total += candidate.state.Weight
+		}
+		pick := rand.IntN(total)
+		for i, candidate := range remaining {
+			if pick < candidate.state.Weight {
+				ordered = append(ordered, candidate)
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				break
+			}
+			pick -= candidate.state.Weight
+		}
+	}
+	return ordered
+}
+
+// probe checks a down relay. Relays that cannot be probed are given a trial delivery.
+func (m *RelayMailer) probe(relay *relayState) bool {
+	prober, ok := relay.Mailer.(relayProber)
+	if !ok {
+		return true
+	}
+	if err := prober.Probe(); err != nil {
+		m.logger.Warn("Mail relay probe failed", zap.String("relay", relay.Name), zap.Error(err))
+		return false
+	}
+	m.markHealthy(relay)
+	return true
+}
+
+func (m *RelayMailer) markHealthy(relay *relayState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	relay.failures = 0
+	if relay.down {
+		relay.down = false
+		observability.SetRelayUp(relay.Name, true)
+		m.logger.Info("Mail relay is back up", zap.String("relay", relay.Name))
+	}
+}
+
+func (m *RelayMailer) markFailed(relay *relayState, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	relay.failures++
+	if relay.down {
+		relay.nextProbe = m.now().Add(m.probeInterval)
+		return
+	}
+	if relay.failures >= m.failureThreshold {
+		relay.down = true
+		relay.nextProbe = m.now().Add(m.probeInterval)
+		observability.SetRelayUp(relay.Name, false)
+		m.logger.Warn("Mail relay marked down",
+			zap.String("relay", relay.Name),
+			zap.Int("failures", relay.failures),
+			zap.Duration("probe_in", m.probeInterval),
+			zap.Error(err),
+		)
+		return
+	}
+	m.logger.Warn("Mail relay failed, trying next relay",
+		zap.String("relay", relay.Name),
+		zap.Int("failures", relay.failures),
+		zap.Error(err),
+	)
+}
+
+// Close releases the resources of every relay that holds any, such as pooled sessions.
+func (m *RelayMailer) Close() error {
+	var errs []error
+	for _, relay := range m.relays {
+		if closer, ok := relay.Mailer.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,97 @@
+package infrastructure
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"monolith-domain/internal/mailer/domain"
+	"monolith-domain/internal/mailer/infrastructure/smtptest"
+	"monolith-domain/pkg/observability"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TestRelayMailerFailover routes a message over a primary and a backup relay while the
+// primary refuses one command, and checks whether the backup is tried.
+func TestRelayMailerFailover(t *testing.T) {
+	tests := []struct {
+		name         string
+		verb         string
+		reply        string
+		wantFailover bool
+	}{
+		{name: "fails over on refused login", verb: "AUTH", reply: "535 5.7.8 Authentication credentials invalid", wantFailover: true},
+		{name: "fails over on refused sender", verb: "MAIL", reply: "550 5.7.1 Sender not allowed", wantFailover: true},
+		{name: "fails over on unavailable service", verb: "MAIL", reply: "421 4.3.2 Service not available", wantFailover: true},
+		{name: "fails over on session closed at recipient", verb: "RCPT", reply: "421 4.4.2 Closing connection", wantFailover: true},
+		{name: "stops at rejected recipient", verb: "RCPT", reply: "550 5.1.1 No such user"},
+		{name: "stops at deferred recipient", verb: "RCPT", reply: "450 4.2.1 Try again later"},
+		{name: "stops at rejected content", verb: "DATA", reply: "554 5.6.0 Message refused"},
+		{name: "stops at deferred content", verb: "DATA", reply: "451 4.3.0 Try again later"},
+	}
+
+	registry := prometheus.NewRegistry()
+	observability.RegisterMailMetrics(registry)
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary, backup := newTestServer(t, smtptest.ModePlain), newTestServer(t, smtptest.ModePlain)
+			primary.Reject(tt.verb, tt.reply)
+
+			name := fmt.Sprintf("primary-%d", i)
+			relay, err := NewRelayMailer([]Relay{
+				{Name: name, Priority: 1, Mailer: newTestSMTPMailer(t, primary, "user", "secret", TLSOptions{Mode: TLSModePlain}, PoolOptions{})},
+				{Name: name + "-backup", Priority: 2, Mailer: newTestSMTPMailer(t, backup, "user", "secret", TLSOptions{Mode: TLSModePlain}, PoolOptions{})},
+			}, 1, time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = relay.Send(testMail)
+			delivered := len(backup.Messages())
+			up := relayUp(t, registry, name)
+
+			if tt.wantFailover {
+				if err != nil || delivered != 1 {
+					t.Fatalf("expected delivery through the backup, got %d messages and %v", delivered, err)
+				}
+				if up != 0 {
+					t.Error("expected mail_relay_up to report the primary down")
+				}
+				return
+			}
+			wantPermanent := strings.HasPrefix(tt.reply, "5")
+			if err == nil || domain.IsPermanent(err) != wantPermanent || delivered != 0 {
+				t.Fatalf("expected a failure with permanent=%v without trying the backup, got %d messages and %v", wantPermanent, delivered, err)
+			}
+			if up != 1 {
+				t.Error("expected mail_relay_up to report the primary up")
+			}
+		})
+	}
+}
+
+// relayUp reads the mail_relay_up gauge of relay from registry.
+func relayUp(t *testing.T, registry *prometheus.Registry, relay string) float64 {
+	t.Helper()
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "mail_relay_up" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "relay" && label.GetValue() == relay {
+					return metric.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+	t.Fatalf("mail_relay_up has no series for relay %s", relay)
+	return 0
+}
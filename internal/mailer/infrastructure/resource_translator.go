@@ -0,0 +1,48 @@
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+
+	"monolith-domain/internal/mailer/domain"
+	resourcedomain "monolith-domain/internal/resources/domain"
+
+	"gorm.io/gorm"
+)
+
+// resourceLookup is the part of the resources bounded context the mailer depends on.
+type resourceLookup interface {
+	GetResourceByKeyAndLang(key, langCode string) (*resourcedomain.Resource, error)
+}
+
+// ResourceTranslator serves template translations from the resources table.
+type ResourceTranslator struct {
+	resources    resourceLookup
+	fallbackLang string
+}
+
+func NewResourceTranslator(resources resourceLookup, fallbackLang string) *ResourceTranslator {
+	return &ResourceTranslator{
+		resources:    resources,
+		fallbackLang: fallbackLang,
+	}
+}
+
+// Translate returns the value stored for key in langCode, or in the fallback
+// language when langCode is empty or has no such key.
+func (t *ResourceTranslator) Translate(key, langCode string) (string, error) {
+	for _, lang := range []string{langCode, t.fallbackLang} {
+		if lang == "" {
+			continue
+		}
+		resource, err := t.resources.GetResourceByKeyAndLang(key, lang)
+		if err == nil {
+			return resource.Value, nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", err
+		}
+	}
+
+	return "", fmt.Errorf("%w: %q (lang %q, fallback %q)", domain.ErrTranslationNotFound, key, langCode, t.fallbackLang)
+}
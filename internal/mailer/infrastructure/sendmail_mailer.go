@@ -0,0 +1,113 @@
+package infrastructure
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"monolith-domain/internal/mailer/domain"
+	"monolith-domain/pkg/observability"
+
+	"go.uber.org/zap"
+)
+
+// DefaultSendmailPath is used when no sendmail binary is configured.
+const DefaultSendmailPath = "/usr/sbin/sendmail"
+
+const defaultSendmailTimeout = time.Minute
+
+// sendmailWaitDelay is how long output pipes may stay open after sendmail was killed,
+// e.g. by a child process it forked, before they are closed forcibly.
+const sendmailWaitDelay = 5 * time.Second
+
+// permanentSendmailExitCodes are the sysexits.h codes that retrying will not fix:
+// EX_USAGE, EX_DATAERR, EX_NOUSER and EX_NOHOST.
+var permanentSendmailExitCodes = map[int]bool{64: true, 65: true, 67: true, 68: true}
+
+// SendmailMailer hands messages to a local sendmail-compatible binary over stdin.
+type SendmailMailer struct {
+	path    string
+	args    []string
+	timeout time.Duration
+	builder *MessageBuilder
+	logger  *zap.Logger
+}
+
+// NewSendmailMailer creates a mailer that runs path with args, followed by the
+// envelope sender and recipients, for every message. A run taking longer than timeout
+// is killed and retried later; a zero timeout uses one minute.
+func NewSendmailMailer(path string, args []string, timeout time.Duration, builder *MessageBuilder) *SendmailMailer {
+	if path == "" {
+		path = DefaultSendmailPath
+	}
+	if timeout <= 0 {
+		timeout = defaultSendmailTimeout
+	}
+	return &SendmailMailer{
+		path:    path,
+		args:    args,
+		timeout: timeout,
+		builder: builder,
+		logger:  observability.GetLogger(),
+	}
+}
+
+// Send pipes the built message to sendmail. Lines starting with a dot are not treated
+// as the end of input (-i) and the envelope sender is set with -f.
+func (m *SendmailMailer) Send(mail domain.Mail) (*domain.DeliveryReceipt, error) {
+	receipt := &domain.DeliveryReceipt{Transport: "sendmail"}
+	message, err := m.builder.Build(mail)
+	if err != nil {
+		return receipt, fmt.Errorf("failed to build email: %w", &domain.DeliveryError{Permanent: true, Err: err})
+	}
+	receipt.MessageID = message.MessageID
+
+	args := append(append([]string{}, m.args...), "-i", "-f", message.From, "--")
+	args = append(args, message.Recipients...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, m.path, args...)
+	cmd.Stdin = bytes.NewReader(message.Data)
+	cmd.Stderr = &stderr
+	cmd.WaitDelay = sendmailWaitDelay
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			err = fmt.Errorf("sendmail did not finish within %s: %w", m.timeout, err)
+		}
+		m.logger.Error("Failed to send email",
+			zap.Strings("to", mail.To),
+			zap.String("subject", mail.Subject),
+			zap.String("stderr", strings.TrimSpace(stderr.String())),
+			zap.Error(err),
+		)
+		return receipt, fmt.Errorf("failed to send email: %w", classifySendmailError(err, stderr.String()))
+	}
+
+	m.logger.Info("Email sent successfully",
+		zap.Strings("to", mail.To),
+		zap.String("message_id", message.MessageID),
+		zap.String("subject", mail.Subject),
+		zap.String("transport", "sendmail"),
+	)
+	return receipt, nil
+}
+
+func classifySendmailError(err error, stderr string) error {
+	if stderr = strings.TrimSpace(stderr); stderr != "" {
+		err = fmt.Errorf("%w: %s", err, stderr)
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &domain.DeliveryError{Permanent: permanentSendmailExitCodes[exitErr.ExitCode()], Err: err}
+	}
+	return &domain.DeliveryError{Err: err}
+}
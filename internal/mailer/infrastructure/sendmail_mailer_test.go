@@ -0,0 +1,97 @@
+package infrastructure
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"monolith-domain/internal/mailer/domain"
+)
+
+// sendmailMail has a body line holding a single dot, which sendmail must receive as is.
+var sendmailMail = domain.Mail{To: []string{"recipient@example.com"}, Subject: "test", TextBody: "hello\n.\nstill the body"}
+
+// sendmailStandIn writes a script that runs body in place of sendmail. The tests need
+// /bin/sh to run it.
+func sendmailStandIn(t *testing.T, dir, body string) string {
+	t.Helper()
+	path := filepath.Join(dir, "sendmail")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSendmailMailerPipesMessage(t *testing.T) {
+	dir := t.TempDir()
+	argsFile, messageFile := filepath.Join(dir, "args"), filepath.Join(dir, "message")
+	path := sendmailStandIn(t, dir, fmt.Sprintf(`printf '%%s\n' "$@" > %q && cat > %q`, argsFile, messageFile))
+
+	mailer := NewSendmailMailer(path, []string{"-oem"}, time.Minute, NewMessageBuilder("sender@example.com", "test"))
+	receipt, err := mailer.Send(sendmailMail)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	args, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(strings.Fields(string(args)), " "), "-oem -i -f sender@example.com -- recipient@example.com"; got != want {
+		t.Errorf("expected arguments %q, got %q", want, got)
+	}
+	message, err := os.ReadFile(messageFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(message, []byte("Message-ID: "+receipt.MessageID+"\r\n")) || !bytes.Contains(message, []byte("\r\n.\r\nstill the body")) {
+		t.Errorf("sendmail did not receive the built message unchanged:\n%s", message)
+	}
+}
+
+func TestSendmailMailerClassifiesExitCodes(t *testing.T) {
+	tests := []struct {
+		name          string
+		code          int
+		wantPermanent bool
+	}{
+		{name: "usage error is permanent", code: 64, wantPermanent: true},
+		{name: "temporary failure is retried", code: 75},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := sendmailStandIn(t, t.TempDir(), fmt.Sprintf("cat > /dev/null\necho 'stand-in failure' >&2\nexit %d", tt.code))
+			_, err := NewSendmailMailer(path, nil, time.Minute, NewMessageBuilder("sender@example.com", "test")).Send(sendmailMail)
+			expectFailure(t, err, tt.wantPermanent)
+		})
+	}
+}
+
+func TestSendmailMailerTimesOut(t *testing.T) {
+	// The shell forks sleep, which keeps stderr open after the shell itself is killed
+	path := sendmailStandIn(t, t.TempDir(), "sleep 30")
+
+	const timeout = 200 * time.Millisecond
+	start := time.Now()
+	_, err := NewSendmailMailer(path, nil, timeout, NewMessageBuilder("sender@example.com", "test")).Send(sendmailMail)
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Fatalf("send took %v with a %v timeout", elapsed, timeout)
+	}
+	expectFailure(t, err, false)
+}
+
+// expectFailure checks that err is a send failure of the expected kind.
+func expectFailure(t *testing.T, err error, wantPermanent bool) {
+	t.Helper()
+	if err == nil {
+		t.Fatal("expected the send to fail")
+	}
+	if domain.IsPermanent(err) != wantPermanent {
+		t.Errorf("expected permanent=%v, got %v", wantPermanent, err)
+	}
+}
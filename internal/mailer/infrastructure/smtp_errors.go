@@ -0,0 +1,71 @@
+package infrastructure
+
+import (
+	"errors"
+	"fmt"
+	"net/textproto"
+
+	"monolith-domain/internal/mailer/domain"
+)
+
+// rejectedRecipientError is a failed RCPT TO, kept apart so the refused address
+// can be reported.
+type rejectedRecipientError struct {
+	recipient string
+	err       error
+}
+
+func (e *rejectedRecipientError) Error() string {
+	return fmt.Sprintf("recipient %s rejected: %v", e.recipient, e.err)
+}
+
+func (e *rejectedRecipientError) Unwrap() error {
+	return e.err
+}
+
+// transactionError is a failed MAIL FROM or DATA. Like a rejected RCPT TO it is an
+// answer about this message, unlike failures while setting up the session.
+type transactionError struct {
+	command string
+	err     error
+}
+
+func (e *transactionError) Error() string {
+	return fmt.Sprintf("%s failed: %v", e.command, e.err)
+}
+
+func (e *transactionError) Unwrap() error {
+	return e.err
+}
+
+// classifySMTPError turns an error from the SMTP conversation into a domain.DeliveryError.
+// Only 5xx replies within the mail transaction (MAIL FROM, RCPT TO, DATA) are permanent;
+// a 5xx while connecting, at EHLO, STARTTLS or AUTH points at the relay or our own
+// configuration and is retried like 4xx replies and anything without a reply code
+// (dial failures, timeouts, dropped connections). Replies before RCPT TO are route
+// failures: a relay refusing our login or sender says nothing about other relays. So is
+// a 421 at any point, the server closing the session.
+func classifySMTPError(err error) *domain.DeliveryError {
+	var protoErr *textproto.Error
+	if !errors.As(err, &protoErr) {
+		return &domain.DeliveryError{Err: err}
+	}
+
+	deliveryErr := &domain.DeliveryError{Code: protoErr.Code, RouteFailure: true, Err: err}
+	permanent := protoErr.Code >= 500 && protoErr.Code < 600
+	var rcptErr *rejectedRecipientError
+	var txErr *transactionError
+	switch {
+	case errors.As(err, &rcptErr):
+		deliveryErr.Recipient = rcptErr.recipient
+		deliveryErr.Permanent = permanent
+		deliveryErr.RouteFailure = false
+	case errors.As(err, &txErr):
+		deliveryErr.Permanent = permanent
+		deliveryErr.RouteFailure = txErr.command == "MAIL FROM"
+	}
+	if protoErr.Code == 421 {
+		deliveryErr.RouteFailure = true
+	}
+	return deliveryErr
+}
@@ -1,83 +1,309 @@
 package infrastructure
 
 import (
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"net"
 	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+
 	"monolith-domain/internal/mailer/domain"
-	"go.uber.org/zap"
 	"monolith-domain/pkg/observability"
+
+	"go.uber.org/zap"
 )
 
+// smtpDefaultTimeout bounds connecting and every exchange with the server unless
+// WithTimeout sets another limit.
+const smtpDefaultTimeout = time.Minute
+
 // SMTPMailer handles email sending via SMTP.
 type SMTPMailer struct {
-	host     string
-	port     int
-	username string
-	password string
-	from     string
-	secure   bool
-	logger   *zap.Logger
+	host      string
+	port      int
+	username  string
+	password  string
+	builder   *MessageBuilder
+	tlsMode   TLSMode
+	tlsConfig *tls.Config
+	timeout   time.Duration
+	pool      *smtpPool
+	logger    *zap.Logger
 }
 
 // NewSMTPMailer initializes a new SMTPMailer with the provided configuration.
-func NewSMTPMailer(host string, port int, username, password, from string, secure bool) (*SMTPMailer, error) {
-	return &SMTPMailer{
-		host:     host,
-		port:     port,
-		username: username,
-		password: password,
-		from:     from,
-		secure:   secure,
-		logger:   observability.GetLogger(),
-	}, nil
+// Messages are rendered (and DKIM signed, if configured) by builder and sent over
+// sessions reused according to poolOptions.
+func NewSMTPMailer(host string, port int, username, password string, tlsOptions TLSOptions, poolOptions PoolOptions, builder *MessageBuilder) (*SMTPMailer, error) {
+	tlsMode, err := resolveTLSMode(tlsOptions, port)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig, err := buildTLSConfig(tlsOptions, host)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &SMTPMailer{
+		host:      host,
+		port:      port,
+		username:  username,
+		password:  password,
+		builder:   builder,
+		tlsMode:   tlsMode,
+		tlsConfig: tlsConfig,
+		timeout:   smtpDefaultTimeout,
+		logger:    observability.GetLogger(),
+	}
+	m.pool = newSMTPPool(m.dial, poolOptions)
+	return m, nil
+}
+
+// WithTimeout limits how long connecting, each command and the transfer of the message
+// data may take before the session is abandoned and the delivery retried. Zero or less
+// keeps the default.
+func (m *SMTPMailer) WithTimeout(timeout time.Duration) *SMTPMailer {
+	if timeout > 0 {
+		m.timeout = timeout
+	}
+	return m
 }
 
 // Send sends an email using SMTP with the provided parameters.
 // It logs the attempt and any errors that occur during the process.
-func (m *SMTPMailer) Send(mail domain.Mail) error {
+func (m *SMTPMailer) Send(mail domain.Mail) (*domain.DeliveryReceipt, error) {
 	m.logger.Info("Attempting to send email",
-		zap.String("to", mail.To),
+		zap.Strings("to", mail.To),
+		zap.Int("recipients", len(mail.Recipients())),
 		zap.String("subject", mail.Subject),
-		zap.String("from", m.from),
+		zap.String("from", m.builder.from),
 		zap.String("host", m.host),
 		zap.Int("port", m.port),
+		zap.String("tls_mode", string(m.tlsMode)),
 	)
 
-	auth := smtp.PlainAuth("", m.username, m.password, m.host)
-
-	headers := make(map[string]string)
-	headers["From"] = m.from
-	headers["To"] = mail.To
-	headers["Subject"] = mail.Subject
-
-	if mail.IsHTML {
-		headers["MIME-Version"] = "1.0"
-		headers["Content-Type"] = "text/html; charset=UTF-8"
-	} else {
-		headers["Content-Type"] = "text/plain; charset=UTF-8"
+	receipt := &domain.DeliveryReceipt{Transport: "smtp://" + net.JoinHostPort(m.host, strconv.Itoa(m.port))}
+	message, err := m.builder.Build(mail)
+	if err != nil {
+		return receipt, fmt.Errorf("failed to build email: %w", &domain.DeliveryError{Permanent: true, Err: err})
 	}
+	receipt.MessageID = message.MessageID
 
-	message := ""
-	for key, value := range headers {
-		message += fmt.Sprintf("%s: %s\r\n", key, value)
+	response, rejected, err := m.sendMail(message)
+	for _, rejection := range rejected {
+		receipt.Rejected = append(receipt.Rejected, classifySMTPError(rejection))
 	}
-	message += "\r\n" + mail.Body
-
-	addr := fmt.Sprintf("%s:%d", m.host, m.port)
-	err := smtp.SendMail(addr, auth, m.from, []string{mail.To}, []byte(message))
 	if err != nil {
 		m.logger.Error("Failed to send email",
-			zap.String("to", mail.To),
+			zap.Strings("to", mail.To),
 			zap.String("subject", mail.Subject),
 			zap.Error(err),
 		)
-		return fmt.Errorf("failed to send email: %w", err)
+		return receipt, fmt.Errorf("failed to send email: %w", classifySMTPError(err))
+	}
+	receipt.Response = response
+	for _, rejection := range rejected {
+		m.logger.Warn("Recipient refused, sent to the others",
+			zap.String("recipient", rejection.recipient),
+			zap.String("message_id", message.MessageID),
+			zap.Error(rejection.err),
+		)
 	}
 
 	m.logger.Info("Email sent successfully",
-		zap.String("to", mail.To),
+		zap.Strings("to", mail.To),
+		zap.String("message_id", message.MessageID),
 		zap.String("subject", mail.Subject),
+		zap.String("response", response),
 	)
 
-	return nil
+	return receipt, nil
+}
+
+// sendMail runs one SMTP transaction on a pooled session and returns the server's
+// reply to the message data and the recipients it refused. It replaces smtp.SendMail
+// so the TLS mode and tls.Config are honoured and connections are reused.
+func (m *SMTPMailer) sendMail(message *BuiltMessage) (string, []*rejectedRecipientError, error) {
+	session, err := m.pool.get()
+	if err != nil {
+		return "", nil, err
+	}
+
+	response, rejected, err := transact(session, message)
+	m.pool.put(session, err)
+	return response, rejected, err
+}
+
+// transact sends MAIL, RCPT and DATA, each under a fresh deadline. A refused recipient
+// is skipped and the message goes to the accepted ones; only when every recipient is
+// refused does the transaction fail. DATA is driven through the underlying text
+// connection because smtp.Client discards the final reply, which carries the server's
+// queue ID.
+func transact(session *smtpSession, message *BuiltMessage) (string, []*rejectedRecipientError, error) {
+	client := session.client
+	session.arm()
+	if err := client.Mail(message.From); err != nil {
+		return "", nil, &transactionError{command: "MAIL FROM", err: err}
+	}
+
+	var rejected []*rejectedRecipientError
+	for _, recipient := range message.Recipients {
+		session.arm()
+		if err := client.Rcpt(recipient); err != nil {
+			rejection := &rejectedRecipientError{recipient: recipient, err: err}
+			var protoErr *textproto.Error
+			if !errors.As(err, &protoErr) {
+				// No reply: the session is broken, not the recipient refused
+				return "", nil, rejection
+			}
+			rejected = append(rejected, rejection)
+		}
+	}
+	if len(rejected) == len(message.Recipients) {
+		return "", rejected, allRejected(rejected)
+	}
+
+	response, err := data(session, message.Data)
+	if err != nil {
+		return "", rejected, &transactionError{command: "DATA", err: err}
+	}
+	return response, rejected, nil
+}
+
+// allRejected picks the error for a message refused for every recipient: a transient
+// rejection if there is one, since a retry may still reach that recipient.
+func allRejected(rejected []*rejectedRecipientError) error {
+	for _, rejection := range rejected {
+		var protoErr *textproto.Error
+		if errors.As(rejection, &protoErr) && (protoErr.Code < 500 || protoErr.Code > 599) {
+			return rejection
+		}
+	}
+	return rejected[0]
+}
+
+// data sends the message content and returns the final reply.
+func data(session *smtpSession, content []byte) (string, error) {
+	text := session.client.Text
+	session.arm()
+	id, err := text.Cmd("DATA")
+	if err != nil {
+		return "", err
+	}
+	text.StartResponse(id)
+	_, _, err = text.ReadResponse(354)
+	text.EndResponse(id)
+	if err != nil {
+		return "", err
+	}
+
+	session.arm()
+	w := text.DotWriter()
+	if _, err := w.Write(content); err != nil {
+		w.Close()
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+
+	session.arm()
+	code, msg, err := text.ReadResponse(250)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d %s", code, msg), nil
+}
+
+// Close ends the idle pooled sessions.
+func (m *SMTPMailer) Close() error {
+	return m.pool.Close()
+}
+
+// dial connects to the server, secures the connection according to the TLS mode
+// and authenticates when credentials are configured. The greeting, EHLO, STARTTLS and
+// AUTH each get the mailer timeout to complete.
+func (m *SMTPMailer) dial() (*smtpSession, error) {
+	addr := net.JoinHostPort(m.host, strconv.Itoa(m.port))
+	dialer := &net.Dialer{Timeout: m.timeout}
+
+	var conn net.Conn
+	var err error
+	if m.tlsMode == TLSModeImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, m.tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	session := &smtpSession{conn: conn, timeout: m.timeout}
+	session.arm()
+	client, err := smtp.NewClient(conn, m.host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	session.client = client
+
+	// Greet explicitly: smtp.Client would otherwise greet lazily with the first command
+	// and a refused EHLO would surface as a rejected MAIL FROM
+	session.arm()
+	if err := client.Hello("localhost"); err != nil {
+		client.Close()
+		return nil, err
+	}
+
+	if m.tlsMode == TLSModeStartTLS || m.tlsMode == TLSModeOpportunistic {
+		session.arm()
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			session.arm()
+			if err := client.StartTLS(m.tlsConfig); err != nil {
+				client.Close()
+				return nil, err
+			}
+		} else if m.tlsMode == TLSModeStartTLS {
+			client.Close()
+			return nil, fmt.Errorf("smtp server %s does not support STARTTLS", m.host)
+		}
+	}
+
+	// Configured credentials are never skipped: a relay that does not offer AUTH (often
+	// only before STARTTLS) would otherwise get the mail unauthenticated
+	if m.username != "" {
+		session.arm()
+		if ok, _ := client.Extension("AUTH"); !ok {
+			client.Close()
+			return nil, fmt.Errorf("smtp server %s does not support AUTH", m.host)
+		}
+		session.arm()
+		if err := client.Auth(smtp.PlainAuth("", m.username, m.password, m.host)); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
+	return session, nil
+}
+
+// Probe checks that the server accepts a session, including TLS and AUTH, without
+// sending mail. RelayMailer uses it to bring a relay back after an outage.
+func (m *SMTPMailer) Probe() error {
+	session, err := m.dial()
+	if err != nil {
+		return err
+	}
+	defer session.client.Close()
+
+	session.arm()
+	if err := session.client.Noop(); err != nil {
+		return err
+	}
+	session.arm()
+	return session.client.Quit()
 }
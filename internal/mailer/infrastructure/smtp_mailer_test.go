@@ -0,0 +1,259 @@
+package infrastructure
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"monolith-domain/internal/mailer/domain"
+	"monolith-domain/internal/mailer/infrastructure/smtptest"
+)
+
+var testMail = domain.Mail{To: []string{"recipient@example.com"}, Subject: "test", TextBody: "hello"}
+
+func newTestServer(t *testing.T, mode smtptest.Mode) *smtptest.Server {
+	t.Helper()
+	server, err := smtptest.NewServer(mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+	return server
+}
+
+func newTestSMTPMailer(t *testing.T, server *smtptest.Server, username, password string, options TLSOptions, pool PoolOptions) *SMTPMailer {
+	t.Helper()
+	mailer, err := NewSMTPMailer(server.Host(), server.Port(), username, password, options, pool, NewMessageBuilder("sender@example.com", "test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { mailer.Close() })
+	return mailer
+}
+
+func TestSMTPMailerTLSModes(t *testing.T) {
+	tests := []struct {
+		name      string
+		server    smtptest.Mode
+		options   TLSOptions
+		trustCA   bool
+		wantError bool
+		wantTLS   bool
+	}{
+		{name: "plain against plain server", server: smtptest.ModePlain, options: TLSOptions{Mode: TLSModePlain}},
+		{name: "plain ignores STARTTLS", server: smtptest.ModeStartTLS, options: TLSOptions{Mode: TLSModePlain}},
+		{name: "starttls upgrades", server: smtptest.ModeStartTLS, options: TLSOptions{Mode: TLSModeStartTLS}, trustCA: true, wantTLS: true},
+		{name: "starttls required but not offered", server: smtptest.ModePlain, options: TLSOptions{Mode: TLSModeStartTLS}, trustCA: true, wantError: true},
+		{name: "starttls rejects untrusted certificate", server: smtptest.ModeStartTLS, options: TLSOptions{Mode: TLSModeStartTLS}, wantError: true},
+		{name: "opportunistic upgrades when offered", server: smtptest.ModeStartTLS, options: TLSOptions{Mode: TLSModeOpportunistic}, trustCA: true, wantTLS: true},
+		{name: "opportunistic falls back to plain", server: smtptest.ModePlain, options: TLSOptions{Mode: TLSModeOpportunistic}},
+		{name: "implicit tls", server: smtptest.ModeImplicitTLS, options: TLSOptions{Mode: TLSModeImplicit}, trustCA: true, wantTLS: true},
+		{name: "implicit tls with TLS 1.3 minimum", server: smtptest.ModeImplicitTLS, options: TLSOptions{Mode: TLSModeImplicit, MinVersion: "1.3"}, trustCA: true, wantTLS: true},
+		{name: "implicit tls with server name override", server: smtptest.ModeImplicitTLS, options: TLSOptions{Mode: TLSModeImplicit, ServerName: "localhost"}, trustCA: true, wantTLS: true},
+		{name: "implicit tls with wrong server name", server: smtptest.ModeImplicitTLS, options: TLSOptions{Mode: TLSModeImplicit, ServerName: "smtp.example.com"}, trustCA: true, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, tt.server)
+			options := tt.options
+			if tt.trustCA {
+				options.CAFile = filepath.Join(t.TempDir(), "ca.pem")
+				if err := os.WriteFile(options.CAFile, server.CertPEM, 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			mailer := newTestSMTPMailer(t, server, "user", "secret", options, PoolOptions{})
+
+			receipt, err := mailer.Send(testMail)
+			if tt.wantError {
+				if err == nil {
+					t.Fatal("expected an error, message was sent")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if receipt == nil || receipt.Response != "250 2.0.0 OK queued" {
+				t.Errorf("expected the final DATA reply in the receipt, got %+v", receipt)
+			}
+			messages := server.Messages()
+			if len(messages) != 1 {
+				t.Fatalf("expected 1 message, server received %d", len(messages))
+			}
+			if messages[0].TLS != tt.wantTLS {
+				t.Errorf("expected TLS=%v, got TLS=%v", tt.wantTLS, messages[0].TLS)
+			}
+		})
+	}
+}
+
+// TestSMTPMailerRequiresAuth checks that configured credentials are used or the send
+// fails, and never skipped because the server does not offer AUTH.
+func TestSMTPMailerRequiresAuth(t *testing.T) {
+	tests := []struct {
+		name      string
+		username  string
+		advertise bool
+		wantError bool
+	}{
+		{name: "authenticates when offered", username: "user", advertise: true},
+		{name: "fails when credentials are set but AUTH is not offered", username: "user", wantError: true},
+		{name: "sends without credentials when AUTH is not offered"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, smtptest.ModePlain)
+			server.AdvertiseAuth(tt.advertise)
+			mailer := newTestSMTPMailer(t, server, tt.username, "secret", TLSOptions{Mode: TLSModePlain}, PoolOptions{})
+
+			_, err := mailer.Send(testMail)
+			if tt.wantError {
+				if err == nil || !strings.Contains(err.Error(), "AUTH") {
+					t.Fatalf("expected the missing AUTH to fail the send, got %v", err)
+				}
+				if len(server.Messages()) != 0 {
+					t.Error("message was sent without authentication")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// TestSMTPMailerClassifiesRefusals makes the server refuse commands and checks whether
+// the failure is reported as permanent (dead-lettered) or transient (retried).
+func TestSMTPMailerClassifiesRefusals(t *testing.T) {
+	tests := []struct {
+		name          string
+		verbs         []string
+		reply         string
+		wantPermanent bool
+	}{
+		{name: "rejected greeting is retried", verbs: []string{"EHLO", "HELO"}, reply: "554 5.7.1 Client host blocked"},
+		{name: "auth failure is retried", verbs: []string{"AUTH"}, reply: "535 5.7.8 Authentication credentials invalid"},
+		{name: "rejected sender is permanent", verbs: []string{"MAIL"}, reply: "550 5.7.1 Sender not allowed", wantPermanent: true},
+		{name: "rejected recipient is permanent", verbs: []string{"RCPT"}, reply: "550 5.1.1 No such user", wantPermanent: true},
+		{name: "rejected content is permanent", verbs: []string{"DATA"}, reply: "554 5.6.0 Message refused", wantPermanent: true},
+		{name: "deferred recipient is retried", verbs: []string{"RCPT"}, reply: "450 4.2.1 Try again later"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, smtptest.ModePlain)
+			for _, verb := range tt.verbs {
+				server.Reject(verb, tt.reply)
+			}
+			mailer := newTestSMTPMailer(t, server, "user", "secret", TLSOptions{Mode: TLSModePlain}, PoolOptions{})
+
+			_, err := mailer.Send(testMail)
+			if err == nil {
+				t.Fatalf("expected %s to fail", tt.verbs[0])
+			}
+			if domain.IsPermanent(err) != tt.wantPermanent {
+				t.Errorf("expected permanent=%v, got %v", tt.wantPermanent, err)
+			}
+		})
+	}
+}
+
+// TestSMTPMailerTimesOut makes the server stop answering a command and checks that the
+// send gives up after the mailer timeout with a transient error instead of hanging. A
+// stalled QUIT only delays closing the session after the message was accepted.
+func TestSMTPMailerTimesOut(t *testing.T) {
+	const timeout = 200 * time.Millisecond
+	for _, verb := range []string{"EHLO", "AUTH", "MAIL", "DATA", "QUIT"} {
+		t.Run(verb, func(t *testing.T) {
+			server := newTestServer(t, smtptest.ModePlain)
+			server.Stall(verb, true)
+			mailer := newTestSMTPMailer(t, server, "user", "secret", TLSOptions{Mode: TLSModePlain}, PoolOptions{}).WithTimeout(timeout)
+
+			start := time.Now()
+			_, err := mailer.Send(testMail)
+			if elapsed := time.Since(start); elapsed > 10*timeout {
+				t.Fatalf("send took %v with a %v timeout", elapsed, timeout)
+			}
+			if verb == "QUIT" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected the stalled %s to fail the send", verb)
+			}
+			if domain.IsPermanent(err) {
+				t.Errorf("expected a transient error, got %v", err)
+			}
+		})
+	}
+}
+
+// TestSMTPMailerPartialRejection sends to a To, Cc and Bcc recipient while the server
+// refuses some of them, and checks who received the message and which refusals were
+// reported.
+func TestSMTPMailerPartialRejection(t *testing.T) {
+	tests := []struct {
+		name          string
+		refuse        map[string]string // address -> RCPT TO reply
+		wantDelivered []string          // empty when the send should fail
+		wantPermanent bool
+	}{
+		{name: "refused cc still sends to the others", refuse: map[string]string{"copy@example.com": "550 5.1.1 No such user"}, wantDelivered: []string{"recipient@example.com", "blind@example.com"}},
+		{name: "deferred bcc still sends to the others", refuse: map[string]string{"blind@example.com": "450 4.2.2 Mailbox full"}, wantDelivered: []string{"recipient@example.com", "copy@example.com"}},
+		{name: "all recipients refused fails permanently", refuse: map[string]string{"recipient@example.com": "550 5.1.1 No such user", "copy@example.com": "550 5.1.1 No such user", "blind@example.com": "553 5.1.3 Bad address"}, wantPermanent: true},
+		{name: "all recipients refused with one deferral is retried", refuse: map[string]string{"recipient@example.com": "550 5.1.1 No such user", "copy@example.com": "451 4.3.0 Try later", "blind@example.com": "550 5.1.1 No such user"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, smtptest.ModePlain)
+			for address, reply := range tt.refuse {
+				server.RejectAddress(address, reply)
+			}
+			mailer := newTestSMTPMailer(t, server, "", "", TLSOptions{Mode: TLSModePlain}, PoolOptions{})
+
+			receipt, err := mailer.Send(domain.Mail{
+				To:       []string{"recipient@example.com"},
+				Cc:       []string{"copy@example.com"},
+				Bcc:      []string{"blind@example.com"},
+				Subject:  "test",
+				TextBody: "hello",
+			})
+			if receipt == nil || len(receipt.Rejected) != len(tt.refuse) {
+				t.Fatalf("expected %d refused recipients in the receipt, got %+v", len(tt.refuse), receipt)
+			}
+			for _, rejection := range receipt.Rejected {
+				reply := tt.refuse[rejection.Recipient]
+				if reply == "" || domain.IsPermanent(rejection) != strings.HasPrefix(reply, "5") {
+					t.Errorf("unexpected rejection %v", rejection)
+				}
+			}
+
+			if len(tt.wantDelivered) == 0 {
+				if err == nil {
+					t.Fatal("expected the send to fail")
+				}
+				if domain.IsPermanent(err) != tt.wantPermanent {
+					t.Errorf("expected permanent=%v, got %v", tt.wantPermanent, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			messages := server.Messages()
+			if len(messages) != 1 || strings.Join(messages[0].To, ",") != strings.Join(tt.wantDelivered, ",") {
+				t.Errorf("expected delivery to %v, server received %+v", tt.wantDelivered, messages)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package infrastructure
+
+import (
+	"errors"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"sync"
+	"time"
+)
+
+// PoolOptions controls how SMTP sessions are reused between messages.
+type PoolOptions struct {
+	// Size is the number of idle sessions kept open. Zero disables pooling and
+	// every message gets its own connection.
+	Size int
+	// MaxMessages closes a session after this many messages; zero means no limit.
+	MaxMessages int
+	// IdleTimeout closes sessions that were not used for this long, before the
+	// server drops them on its own.
+	IdleTimeout time.Duration
+}
+
+// smtpSession is an authenticated connection and the number of messages it carried.
+type smtpSession struct {
+	client   *smtp.Client
+	conn     net.Conn // Underlying connection, also beneath STARTTLS, for deadlines
+	timeout  time.Duration
+	messages int
+	lastUsed time.Time
+}
+
+// arm gives the next exchange with the server timeout to complete, so a stalled server
+// fails the delivery instead of blocking the worker.
+func (s *smtpSession) arm() {
+	if s.timeout > 0 {
+		s.conn.SetDeadline(time.Now().Add(s.timeout))
+	}
+}
+
+// smtpPool keeps authenticated SMTP sessions alive so consecutive messages skip the
+// connect, EHLO, STARTTLS and AUTH round trips. Reused sessions are reset with RSET,
+// which also detects connections the server has dropped in the meantime.
+type smtpPool struct {
+	dial    func() (*smtpSession, error)
+	options PoolOptions
+	now     func() time.Time
+
+	mu     sync.Mutex
+	idle   []*smtpSession
+	closed bool
+}
+
+func newSMTPPool(dial func() (*smtpSession, error), options PoolOptions) *smtpPool {
+	return &smtpPool{dial: dial, options: options, now: time.Now}
+}
+
+// get returns a ready session: the most recently used idle one that still answers
+// RSET within the session timeout, or a new connection. A half-dead connection that
+// lets RSET time out is dropped like one the server closed.
+func (p *smtpPool) get() (*smtpSession, error) {
+	for {
+		session := p.popIdle()
+		if session == nil {
+			break
+		}
+		if p.expired(session) {
+			session.client.Close()
+			continue
+		}
+		session.arm()
+		if err := session.client.Reset(); err != nil {
+			session.client.Close()
+			continue
+		}
+		return session, nil
+	}
+
+	return p.dial()
+}
+
+func (p *smtpPool) popIdle() *smtpSession {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.idle) == 0 {
+		return nil
+	}
+	session := p.idle[len(p.idle)-1]
+	p.idle = p.idle[:len(p.idle)-1]
+	return session
+}
+
+func (p *smtpPool) expired(session *smtpSession) bool {
+	return p.options.IdleTimeout > 0 && p.now().Sub(session.lastUsed) > p.options.IdleTimeout
+}
+
+// put hands a session back after a transaction. err is the transaction result: a
+// reply from the server leaves the session usable, anything else (a broken
+// connection, a timeout) means it is discarded.
+func (p *smtpPool) put(session *smtpSession, err error) {
+	var protoErr *textproto.Error
+	if err != nil && !errors.As(err, &protoErr) {
+		session.client.Close()
+		return
+	}
+
+	if err == nil {
+		session.messages++
+	}
+	session.lastUsed = p.now()
+
+	// Idle sessions carry no deadline; the next exchange arms its own
+	session.conn.SetDeadline(time.Time{})
+
+	p.mu.Lock()
+	reuse := !p.closed && len(p.idle) < p.options.Size &&
+		(p.options.MaxMessages <= 0 || session.messages < p.options.MaxMessages)
+	if reuse {
+		p.idle = append(p.idle, session)
+	}
+	p.mu.Unlock()
+
+	if !reuse {
+		quit(session)
+	}
+}
+
+// Close ends all idle sessions; sessions in use are closed when they are put back.
+func (p *smtpPool) Close() error {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.closed = true
+	p.mu.Unlock()
+
+	for _, session := range idle {
+		quit(session)
+	}
+	return nil
+}
+
+func quit(session *smtpSession) {
+	session.arm()
+	if err := session.client.Quit(); err != nil {
+		session.client.Close()
+	}
+}
@@ -0,0 +1,74 @@
+package infrastructure
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"monolith-domain/internal/mailer/domain"
+	"monolith-domain/internal/mailer/infrastructure/smtptest"
+)
+
+// TestSMTPPool sends several messages through one mailer and counts the connections
+// the server accepted.
+func TestSMTPPool(t *testing.T) {
+	tests := []struct {
+		name            string
+		pool            PoolOptions
+		sends           int
+		dropAfter       int  // drop all server sessions after this many sends
+		rejectFirst     bool // answer the first RCPT with a 550
+		stallReset      bool // never answer RSET, so every reused session times out
+		wantConnections int
+	}{
+		{name: "reuses one session", pool: PoolOptions{Size: 2}, sends: 5, wantConnections: 1},
+		{name: "replaces sessions after max messages", pool: PoolOptions{Size: 2, MaxMessages: 2}, sends: 5, wantConnections: 3},
+		{name: "disabled opens a session per message", sends: 3, wantConnections: 3},
+		{name: "recovers from dropped connections", pool: PoolOptions{Size: 2}, sends: 4, dropAfter: 2, wantConnections: 2},
+		{name: "keeps session after rejected recipient", pool: PoolOptions{Size: 2}, sends: 3, rejectFirst: true, wantConnections: 1},
+		{name: "replaces a session stalling on RSET", pool: PoolOptions{Size: 2}, sends: 3, stallReset: true, wantConnections: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, smtptest.ModePlain)
+			server.Stall("RSET", tt.stallReset)
+			mailer := newTestSMTPMailer(t, server, "", "", TLSOptions{Mode: TLSModePlain}, tt.pool).WithTimeout(200 * time.Millisecond)
+
+			start := time.Now()
+			wantMessages := tt.sends
+			for i := 0; i < tt.sends; i++ {
+				if tt.dropAfter > 0 && i == tt.dropAfter {
+					server.DropConnections()
+				}
+				if tt.rejectFirst && i == 0 {
+					server.RejectRecipients("550 5.1.1 No such user")
+				} else {
+					server.RejectRecipients("")
+				}
+
+				_, err := mailer.Send(domain.Mail{To: []string{"recipient@example.com"}, Subject: fmt.Sprintf("pool %d", i), TextBody: "hello"})
+				if tt.rejectFirst && i == 0 {
+					if !domain.IsPermanent(err) {
+						t.Fatalf("expected a permanent error for the rejected recipient, got %v", err)
+					}
+					wantMessages--
+					continue
+				}
+				if err != nil {
+					t.Fatalf("send %d: %v", i, err)
+				}
+			}
+
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("%d sends took %v", tt.sends, elapsed)
+			}
+			if got := len(server.Messages()); got != wantMessages {
+				t.Errorf("expected %d messages, server received %d", wantMessages, got)
+			}
+			if got := server.Connections(); got != tt.wantConnections {
+				t.Errorf("expected %d connections, server accepted %d", tt.wantConnections, got)
+			}
+		})
+	}
+}
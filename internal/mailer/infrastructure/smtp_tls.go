@@ -0,0 +1,91 @@
+package infrastructure
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// TLSMode selects how the connection to the SMTP server is secured.
+type TLSMode string
+
+const (
+	// TLSModePlain never encrypts the connection.
+	TLSModePlain TLSMode = "plain"
+	// TLSModeStartTLS upgrades the connection with STARTTLS and fails if the server does not offer it.
+	TLSModeStartTLS TLSMode = "starttls"
+	// TLSModeOpportunistic upgrades with STARTTLS when offered and falls back to plain otherwise.
+	TLSModeOpportunistic TLSMode = "opportunistic"
+	// TLSModeImplicit speaks TLS from the first byte (SMTPS, usually port 465).
+	TLSModeImplicit TLSMode = "tls"
+)
+
+// TLSOptions configures transport security for SMTPMailer.
+type TLSOptions struct {
+	Mode       TLSMode
+	Secure     bool   // Legacy switch, only consulted when Mode is empty
+	CAFile     string // PEM bundle used instead of the system roots
+	ServerName string // Name verified against the certificate, defaults to the SMTP host
+	MinVersion string // "1.0", "1.1", "1.2" or "1.3", defaults to 1.2
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// resolveTLSMode validates the configured mode. Without an explicit mode the old
+// secure flag decides: implicit TLS on 465, required STARTTLS elsewhere, and the
+// opportunistic STARTTLS that smtp.SendMail used to do when secure is false.
+func resolveTLSMode(opts TLSOptions, port int) (TLSMode, error) {
+	switch opts.Mode {
+	case TLSModePlain, TLSModeStartTLS, TLSModeOpportunistic, TLSModeImplicit:
+		return opts.Mode, nil
+	case "":
+		if !opts.Secure {
+			return TLSModeOpportunistic, nil
+		}
+		if port == 465 {
+			return TLSModeImplicit, nil
+		}
+		return TLSModeStartTLS, nil
+	default:
+		return "", fmt.Errorf("unknown SMTP TLS mode %q", opts.Mode)
+	}
+}
+
+func buildTLSConfig(opts TLSOptions, host string) (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName: host,
+		MinVersion: tls.VersionTLS12,
+	}
+
+	if opts.ServerName != "" {
+		config.ServerName = opts.ServerName
+	}
+
+	if opts.MinVersion != "" {
+		version, ok := tlsVersions[opts.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported TLS min version %q", opts.MinVersion)
+		}
+		config.MinVersion = version
+	}
+
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", opts.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
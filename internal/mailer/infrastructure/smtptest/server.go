@@ -0,0 +1,365 @@
+// Package smtptest provides a local SMTP stand-in for exercising the mailer
+// against plain, STARTTLS and implicit TLS servers without a real relay.
+package smtptest
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Mode selects the transport security the stand-in offers.
+type Mode int
+
+const (
+	// ModePlain never offers TLS.
+	ModePlain Mode = iota
+	// ModeStartTLS advertises STARTTLS on a plain listener.
+	ModeStartTLS
+	// ModeImplicitTLS wraps the listener in TLS from the first byte.
+	ModeImplicitTLS
+)
+
+// Message is a mail accepted by the stand-in.
+type Message struct {
+	From string
+	To   []string
+	// Data is the message as transferred, dot-unstuffed and with CRLF line endings.
+	Data []byte
+	// TLS reports whether the message was transferred over an encrypted connection.
+	TLS bool
+}
+
+// Server is a minimal SMTP server listening on a loopback address.
+type Server struct {
+	Addr string
+	Mode Mode
+	// CertPEM is the self-signed certificate presented in TLS modes; trust it
+	// through the mailer's CA bundle option.
+	CertPEM []byte
+
+	listener  net.Listener
+	tlsConfig *tls.Config
+
+	mu          sync.Mutex
+	messages    []Message
+	replies     map[string]string
+	stalls      map[string]bool
+	hideAuth    bool
+	refused     map[string]string
+	conns       map[net.Conn]bool
+	connections int
+	wg          sync.WaitGroup
+}
+
+// NewServer starts a stand-in on 127.0.0.1 with a random port.
+func NewServer(mode Mode) (*Server, error) {
+	return NewServerAt("127.0.0.1:0", mode)
+}
+
+// NewServerAt starts a stand-in on addr.
+func NewServerAt(addr string, mode Mode) (*Server, error) {
+	certPEM, cert, err := selfSignedCertificate()
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if mode == ModeImplicitTLS {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	s := &Server{
+		Addr:      listener.Addr().String(),
+		Mode:      mode,
+		CertPEM:   certPEM,
+		listener:  listener,
+		tlsConfig: tlsConfig,
+		conns:     make(map[net.Conn]bool),
+		replies:   make(map[string]string),
+		stalls:    make(map[string]bool),
+		refused:   make(map[string]string),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Host returns the host part of Addr.
+func (s *Server) Host() string {
+	host, _, _ := net.SplitHostPort(s.Addr)
+	return host
+}
+
+// Port returns the port part of Addr.
+func (s *Server) Port() int {
+	_, port, _ := net.SplitHostPort(s.Addr)
+	n, _ := strconv.Atoi(port)
+	return n
+}
+
+// Messages returns a copy of all accepted messages.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+// RejectRecipients makes every RCPT TO answer with reply, e.g. "450 4.2.1 Try later"
+// or "550 5.1.1 No such user". An empty reply accepts recipients again.
+func (s *Server) RejectRecipients(reply string) {
+	s.Reject("RCPT", reply)
+}
+
+// Reject makes every command with the given verb (AUTH, MAIL, RCPT, DATA, ...) answer
+// with reply instead of being carried out. An empty reply restores the usual answer.
+func (s *Server) Reject(verb, reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if reply == "" {
+		delete(s.replies, verb)
+		return
+	}
+	s.replies[verb] = reply
+}
+
+// RejectAddress makes RCPT TO answer with reply for address only, e.g. "550 5.1.1 No
+// such user", while other recipients are accepted.
+func (s *Server) RejectAddress(address, reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refused[address] = reply
+}
+
+// Stall makes the server read commands with the given verb and never answer them, like
+// a relay that hangs mid-session. Stall(verb, false) answers them again.
+func (s *Server) Stall(verb string, stall bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stalls[verb] = stall
+}
+
+// AdvertiseAuth decides whether EHLO lists the AUTH extension; it does by default.
+func (s *Server) AdvertiseAuth(advertise bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hideAuth = !advertise
+}
+
+// Connections returns the number of connections accepted so far.
+func (s *Server) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.connections
+}
+
+// DropConnections closes all open sessions without a reply, like a server
+// timing out idle clients or restarting.
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+// Close stops the listener, drops open sessions and waits for them to end.
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.DropConnections()
+	s.wg.Wait()
+	return err
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = true
+		s.connections++
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Minute))
+
+	_, secure := conn.(*tls.Conn)
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 smtptest ESMTP ready")
+
+	var current *Message
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		verb = strings.ToUpper(verb)
+
+		s.mu.Lock()
+		reply, stall := s.replies[verb], s.stalls[verb]
+		s.mu.Unlock()
+		if stall {
+			continue
+		}
+		if reply != "" {
+			text.PrintfLine("%s", reply)
+			continue
+		}
+
+		switch verb {
+		case "EHLO", "HELO":
+			extensions := []string{"smtptest", "8BITMIME", "PIPELINING"}
+			s.mu.Lock()
+			if !s.hideAuth {
+				extensions = append(extensions, "AUTH PLAIN LOGIN")
+			}
+			s.mu.Unlock()
+			if s.Mode == ModeStartTLS && !secure {
+				extensions = append(extensions, "STARTTLS")
+			}
+			for i, ext := range extensions {
+				sep := "-"
+				if i == len(extensions)-1 {
+					sep = " "
+				}
+				text.PrintfLine("250%s%s", sep, ext)
+			}
+		case "STARTTLS":
+			if s.Mode != ModeStartTLS || secure {
+				text.PrintfLine("502 5.5.1 STARTTLS not available")
+				continue
+			}
+			text.PrintfLine("220 2.0.0 Ready to start TLS")
+			tlsConn := tls.Server(conn, s.tlsConfig)
+			if err := tlsConn.Handshake(); err != nil {
+				return
+			}
+			conn, secure = tlsConn, true
+			text = textproto.NewConn(conn)
+			current = nil
+		case "AUTH":
+			text.PrintfLine("235 2.7.0 Authentication successful")
+		case "MAIL":
+			current = &Message{From: extractPath(arg), TLS: secure}
+			text.PrintfLine("250 2.1.0 OK")
+		case "RCPT":
+			if current == nil {
+				text.PrintfLine("503 5.5.1 MAIL first")
+				continue
+			}
+			s.mu.Lock()
+			refused := s.refused[extractPath(arg)]
+			s.mu.Unlock()
+			if refused != "" {
+				text.PrintfLine("%s", refused)
+				continue
+			}
+			current.To = append(current.To, extractPath(arg))
+			text.PrintfLine("250 2.1.5 OK")
+		case "DATA":
+			if current == nil || len(current.To) == 0 {
+				text.PrintfLine("503 5.5.1 RCPT first")
+				continue
+			}
+			text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := text.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			// ReadDotBytes normalises line endings to LF; restore the wire form.
+			current.Data = bytes.ReplaceAll(data, []byte("\n"), []byte("\r\n"))
+			s.mu.Lock()
+			s.messages = append(s.messages, *current)
+			s.mu.Unlock()
+			current = nil
+			text.PrintfLine("250 2.0.0 OK queued")
+		case "RSET":
+			current = nil
+			text.PrintfLine("250 2.0.0 OK")
+		case "NOOP":
+			text.PrintfLine("250 2.0.0 OK")
+		case "QUIT":
+			text.PrintfLine("221 2.0.0 Bye")
+			return
+		default:
+			text.PrintfLine("500 5.5.2 Command not recognized")
+		}
+	}
+}
+
+// extractPath turns "FROM:<a@b>" or "TO:<a@b> SIZE=1" into "a@b".
+func extractPath(arg string) string {
+	start := strings.Index(arg, "<")
+	end := strings.Index(arg, ">")
+	if start < 0 || end < start {
+		return ""
+	}
+	return arg[start+1 : end]
+}
+
+func selfSignedCertificate() ([]byte, tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, tls.Certificate{}, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "smtptest"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, tls.Certificate{}, err
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, tls.Certificate{}, err
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	return certPEM, cert, err
+}
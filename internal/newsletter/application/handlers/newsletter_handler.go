@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"monolith-domain/internal/newsletter/application/services"
+	"monolith-domain/internal/sharedkernel"
 	"github.com/gofiber/fiber/v2"
 )
 
@@ -39,6 +41,21 @@ func (h *NewsletterHandler) Subscribe(c *fiber.Ctx) error {
 
 	newsletter, err := h.service.Subscribe(req.Email)
 	if err != nil {
+		if errors.Is(err, sharedkernel.ErrInvalidEmail) || errors.Is(err, sharedkernel.ErrDisposableEmail) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		var domainErr *sharedkernel.DomainError
+		if errors.As(err, &domainErr) {
+			response := fiber.Map{
+				"error": err.Error(),
+			}
+			if domainErr.Suggestion != "" {
+				response["suggestion"] = domainErr.Suggestion
+			}
+			return c.Status(fiber.StatusBadRequest).JSON(response)
+		}
 		if err.Error() == "email already subscribed" {
 			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 				"error": "Email already subscribed",
@@ -5,18 +5,28 @@ import (
 	"encoding/base64"
 	"errors"
 	"monolith-domain/internal/newsletter/domain"
+	"monolith-domain/internal/sharedkernel"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type NewsletterService struct {
-	repo domain.NewsletterRepository
+	repo      domain.NewsletterRepository
+	addresses *sharedkernel.AddressValidator
 }
 
-func NewNewsletterService(repo domain.NewsletterRepository) *NewsletterService {
-	return &NewsletterService{repo: repo}
+func NewNewsletterService(repo domain.NewsletterRepository, addresses *sharedkernel.AddressValidator) *NewsletterService {
+	return &NewsletterService{repo: repo, addresses: addresses}
 }
 
 func (s *NewsletterService) Subscribe(email string) (*domain.Newsletter, error) {
+	// Store the normalised address so the same mailbox cannot subscribe twice
+	address, err := s.addresses.Parse(email)
+	if err != nil {
+		return nil, err
+	}
+	email = address.Email
+
 	// Check if already subscribed
 	existing, err := s.repo.FindByEmail(email)
 	if err == nil && existing != nil {
@@ -46,6 +56,23 @@ func (s *NewsletterService) Unsubscribe(token string) error {
 	return s.repo.Delete(newsletter.ID)
 }
 
+// UnsubscribeEmail removes a subscriber by address, e.g. after mail to it bounced.
+// Addresses that are not subscribed are ignored.
+func (s *NewsletterService) UnsubscribeEmail(email string) error {
+	if address, err := sharedkernel.ParseAddress(email); err == nil {
+		email = address.Email
+	}
+	newsletter, err := s.repo.FindByEmail(email)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.repo.Delete(newsletter.ID)
+}
+
 func (s *NewsletterService) GenerateUnsubscribeToken() string {
 	b := make([]byte, 32)
 	rand.Read(b)
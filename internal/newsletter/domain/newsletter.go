@@ -41,6 +41,7 @@ type NewsletterRepository interface {
 type NewsletterService interface {
 	Subscribe(email string) (*Newsletter, error)
 	Unsubscribe(token string) error
+	UnsubscribeEmail(email string) error
 	GenerateUnsubscribeToken() string
 	GetAllActiveSubscribers() ([]*Newsletter, error)
 }
\ No newline at end of file
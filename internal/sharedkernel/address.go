@@ -0,0 +1,216 @@
+package sharedkernel
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/mail"
+	"os"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// ErrInvalidEmail is returned for a string that is not a usable email address.
+var ErrInvalidEmail = errors.New("invalid email address")
+
+// ErrDisposableEmail is returned for an address at a disposable mail provider.
+var ErrDisposableEmail = errors.New("disposable email addresses are not accepted")
+
+// domainProfile converts domains to their ASCII (punycode) form, lower-casing them and
+// rejecting characters and label lengths DNS does not allow.
+var domainProfile = idna.New(idna.MapForLookup(), idna.BidiRule(), idna.VerifyDNSLength(true))
+
+// Address is a parsed and normalised email address with its optional display name.
+type Address struct {
+	Name  string
+	Email string // Lower-cased, with the domain in punycode
+}
+
+// String formats the address for a header, e.g. "Jane Doe" <jane@example.com>.
+func (a Address) String() string {
+	return (&mail.Address{Name: a.Name, Address: a.Email}).String()
+}
+
+// Domain returns the part of the address after the @.
+func (a Address) Domain() string {
+	return a.Email[strings.LastIndex(a.Email, "@")+1:]
+}
+
+// ParseAddress parses a single RFC 5322 address, with or without a display name
+// ("Jane <jane@example.com>"), and normalises it: the address is lower-cased and an
+// internationalised domain is converted to punycode. Local parts must be ASCII since
+// the transports do not negotiate SMTPUTF8.
+func ParseAddress(raw string) (Address, error) {
+	parsed, err := mail.ParseAddress(strings.TrimSpace(raw))
+	if err != nil {
+		return Address{}, fmt.Errorf("%w: %q", ErrInvalidEmail, raw)
+	}
+
+	at := strings.LastIndex(parsed.Address, "@")
+	local, domain := parsed.Address[:at], parsed.Address[at+1:]
+	if len(local) > 64 {
+		return Address{}, fmt.Errorf("%w: %q has a local part longer than 64 characters", ErrInvalidEmail, raw)
+	}
+	for _, r := range local {
+		if r > 127 {
+			return Address{}, fmt.Errorf("%w: %q has a non-ASCII local part", ErrInvalidEmail, raw)
+		}
+	}
+
+	domain, err = NormalizeDomain(domain)
+	if err != nil {
+		return Address{}, fmt.Errorf("%w: %q: %v", ErrInvalidEmail, raw, err)
+	}
+
+	email := strings.ToLower(local) + "@" + domain
+	if len(email) > 254 {
+		return Address{}, fmt.Errorf("%w: %q is longer than 254 characters", ErrInvalidEmail, raw)
+	}
+	return Address{Name: parsed.Name, Email: email}, nil
+}
+
+// NormalizeDomain returns the lower-cased punycode form of a mail domain. Domains must
+// have at least two labels and a non-numeric top-level label; address literals such as
+// [192.0.2.1] are not accepted.
+func NormalizeDomain(domain string) (string, error) {
+	ascii, err := domainProfile.ToASCII(strings.TrimSuffix(domain, "."))
+	if err != nil {
+		return "", err
+	}
+	dot := strings.LastIndex(ascii, ".")
+	if dot <= 0 || dot == len(ascii)-1 {
+		return "", fmt.Errorf("domain %q is not fully qualified", domain)
+	}
+	if strings.Trim(ascii[dot+1:], "0123456789") == "" {
+		return "", fmt.Errorf("domain %q has a numeric top-level label", domain)
+	}
+	return ascii, nil
+}
+
+// AddressValidator parses addresses and refuses those at disposable mail providers and,
+// with a DomainChecker, those at domains that cannot receive mail. A nil validator
+// only parses.
+type AddressValidator struct {
+	disposable map[string]bool
+	checker    *DomainChecker
+}
+
+// NewAddressValidator creates a validator refusing the given domains and their subdomains.
+func NewAddressValidator(disposableDomains []string) (*AddressValidator, error) {
+	disposable := make(map[string]bool, len(disposableDomains))
+	for _, domain := range disposableDomains {
+		normalized, err := NormalizeDomain(domain)
+		if err != nil {
+			return nil, fmt.Errorf("disposable domain %q: %w", domain, err)
+		}
+		disposable[normalized] = true
+	}
+	return &AddressValidator{disposable: disposable}, nil
+}
+
+// LoadAddressValidator creates a validator with the disposable domains listed in the
+// file at path, one per line; blank lines and lines starting with # are ignored. An
+// empty path gives a validator without a list.
+func LoadAddressValidator(path string) (*AddressValidator, error) {
+	if path == "" {
+		return NewAddressValidator(nil)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var domains []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains = append(domains, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return NewAddressValidator(domains)
+}
+
+// CheckDomainsWith makes the validator verify that address domains can receive mail.
+// A nil checker disables the check.
+func (v *AddressValidator) CheckDomainsWith(checker *DomainChecker) *AddressValidator {
+	v.checker = checker
+	return v
+}
+
+// Parse parses and normalises raw like ParseAddress and refuses disposable addresses
+// and, when domains are checked, undeliverable ones with a *DomainError.
+func (v *AddressValidator) Parse(raw string) (Address, error) {
+	address, err := ParseAddress(raw)
+	if err != nil {
+		return Address{}, err
+	}
+	if v.Disposable(address.Domain()) {
+		return Address{}, fmt.Errorf("%w: %s", ErrDisposableEmail, address.Email)
+	}
+	if v != nil && v.checker != nil {
+		if err := v.checker.Check(address); err != nil {
+			return Address{}, err
+		}
+	}
+	return address, nil
+}
+
+// ParseAll parses raws like Parse. The domains of the addresses are checked together,
+// with their lookups running concurrently. The result at each index belongs to the raw
+// address at that index.
+func (v *AddressValidator) ParseAll(raws []string) ([]Address, []error) {
+	addresses := make([]Address, len(raws))
+	errs := make([]error, len(raws))
+	var checked []Address
+	var positions []int
+	for i, raw := range raws {
+		address, err := ParseAddress(raw)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		if v.Disposable(address.Domain()) {
+			errs[i] = fmt.Errorf("%w: %s", ErrDisposableEmail, address.Email)
+			continue
+		}
+		addresses[i] = address
+		checked = append(checked, address)
+		positions = append(positions, i)
+	}
+
+	if v != nil && v.checker != nil {
+		for n, err := range v.checker.CheckAll(checked) {
+			if err != nil {
+				addresses[positions[n]] = Address{}
+				errs[positions[n]] = err
+			}
+		}
+	}
+	return addresses, errs
+}
+
+// Disposable reports whether a normalised domain, or a domain it belongs to, is on
+// the disposable list.
+func (v *AddressValidator) Disposable(domain string) bool {
+	if v == nil || len(v.disposable) == 0 {
+		return false
+	}
+	for {
+		if v.disposable[domain] {
+			return true
+		}
+		dot := strings.Index(domain, ".")
+		if dot < 0 {
+			return false
+		}
+		domain = domain[dot+1:]
+	}
+}
@@ -0,0 +1,271 @@
+package sharedkernel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"monolith-domain/pkg/observability"
+
+	"go.uber.org/zap"
+)
+
+// ErrUndeliverableDomain is returned for an address whose domain has neither MX nor
+// address records, or publishes a null MX.
+var ErrUndeliverableDomain = errors.New("email domain does not accept mail")
+
+// ErrSuspectedTypo is returned for an address whose domain looks like a misspelt
+// well-known mail domain, when such addresses are refused.
+var ErrSuspectedTypo = errors.New("email domain looks like a typo")
+
+// maxCachedDomains bounds the check cache; expired entries are dropped when it is full.
+const maxCachedDomains = 10000
+
+// commonMailDomains are the domains typo suggestions are drawn from, most used first
+// so ties go to the likelier one.
+var commonMailDomains = []string{
+	"gmail.com", "yahoo.com", "hotmail.com", "outlook.com", "icloud.com", "aol.com",
+	"live.com", "msn.com", "me.com", "mac.com", "googlemail.com", "protonmail.com",
+	"proton.me", "gmx.com", "gmx.de", "gmx.net", "web.de", "mail.com", "yandex.ru",
+	"mail.ru", "yahoo.co.uk", "hotmail.co.uk", "hotmail.fr", "yahoo.fr", "orange.fr",
+	"free.fr", "libero.it", "t-online.de", "comcast.net", "verizon.net", "att.net",
+	"qq.com", "163.com", "ymail.com", "email.com", "fastmail.com", "zoho.com", "aim.com",
+	"gmx.at", "gmx.ch",
+}
+
+// DomainResolver is the part of *net.Resolver the deliverability check needs, so a
+// fake DNS can be injected.
+type DomainResolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// DomainError reports a domain that failed the deliverability check, with the
+// well-known domain it was probably meant to be, if any.
+type DomainError struct {
+	Address    string // The checked address
+	Suggestion string // The address with the suggested domain; empty without suggestion
+	Err        error  // ErrUndeliverableDomain or ErrSuspectedTypo
+}
+
+func (e *DomainError) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("%v: %s (did you mean %s?)", e.Err, e.Address, e.Suggestion)
+	}
+	return fmt.Sprintf("%v: %s", e.Err, e.Address)
+}
+
+func (e *DomainError) Unwrap() error {
+	return e.Err
+}
+
+type domainCheck struct {
+	deliverable bool
+	expires     time.Time
+}
+
+// DomainChecker verifies that a domain can receive mail: it has MX records, or address
+// records to fall back to (RFC 5321 section 5.1), and no null MX (RFC 7505). Results are
+// cached; lookups that fail for other reasons than a missing domain let the address
+// through uncached, so a DNS outage does not stop mail.
+type DomainChecker struct {
+	resolver    DomainResolver
+	timeout     time.Duration
+	ttl         time.Duration
+	rejectTypos bool
+	concurrency int
+	logger      *zap.Logger
+
+	mu    sync.Mutex
+	cache map[string]domainCheck
+}
+
+// NewDomainChecker creates a checker using resolver, waiting at most timeout per domain
+// and caching results for ttl. With rejectTypos, misspellings of well-known domains are
+// refused even when they resolve. CheckAll runs up to concurrency lookups at a time.
+func NewDomainChecker(resolver DomainResolver, timeout, ttl time.Duration, rejectTypos bool, concurrency int) *DomainChecker {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &DomainChecker{
+		resolver:    resolver,
+		timeout:     timeout,
+		ttl:         ttl,
+		rejectTypos: rejectTypos,
+		concurrency: concurrency,
+		logger:      observability.GetLogger(),
+		cache:       make(map[string]domainCheck),
+	}
+}
+
+// Check returns a *DomainError if the domain of address cannot receive mail or, with
+// rejectTypos, looks misspelt.
+func (c *DomainChecker) Check(address Address) error {
+	return c.check(address, c.deliverable(address.Domain()))
+}
+
+// CheckAll checks addresses like Check, resolving their distinct domains concurrently
+// so a bulk request waits for its slowest lookup rather than for all of them in turn.
+// The result at each index is the error for the address at that index.
+func (c *DomainChecker) CheckAll(addresses []Address) []error {
+	deliverable := make(map[string]bool, len(addresses))
+	for _, address := range addresses {
+		deliverable[address.Domain()] = false
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.concurrency)
+	for domain := range deliverable {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(domain string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			ok := c.deliverable(domain)
+			mu.Lock()
+			deliverable[domain] = ok
+			mu.Unlock()
+		}(domain)
+	}
+	wg.Wait()
+
+	errs := make([]error, len(addresses))
+	for i, address := range addresses {
+		errs[i] = c.check(address, deliverable[address.Domain()])
+	}
+	return errs
+}
+
+// check returns the error for address once its domain was resolved.
+func (c *DomainChecker) check(address Address, deliverable bool) error {
+	suggestion := SuggestDomain(address.Domain())
+	if !deliverable {
+		return c.domainError(address, suggestion, ErrUndeliverableDomain)
+	}
+	if c.rejectTypos && suggestion != "" {
+		return c.domainError(address, suggestion, ErrSuspectedTypo)
+	}
+	return nil
+}
+
+func (c *DomainChecker) domainError(address Address, suggestion string, err error) *DomainError {
+	domainErr := &DomainError{Address: address.Email, Err: err}
+	if suggestion != "" {
+		domainErr.Suggestion = address.Email[:strings.LastIndex(address.Email, "@")+1] + suggestion
+	}
+	return domainErr
+}
+
+func (c *DomainChecker) deliverable(domain string) bool {
+	now := time.Now()
+	c.mu.Lock()
+	cached, ok := c.cache[domain]
+	c.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.deliverable
+	}
+
+	deliverable, err := c.lookup(domain)
+	if err != nil {
+		c.logger.Warn("Mail domain lookup failed, accepting the domain", zap.String("domain", domain), zap.Error(err))
+		return true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.cache) >= maxCachedDomains {
+		for key, entry := range c.cache {
+			if now.After(entry.expires) {
+				delete(c.cache, key)
+			}
+		}
+		if len(c.cache) >= maxCachedDomains {
+			c.cache = make(map[string]domainCheck)
+		}
+	}
+	c.cache[domain] = domainCheck{deliverable: deliverable, expires: now.Add(c.ttl)}
+	return deliverable
+}
+
+// lookup resolves domain; an error means the answer is unknown.
+func (c *DomainChecker) lookup(domain string) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	records, err := c.resolver.LookupMX(ctx, domain)
+	if err == nil && len(records) > 0 {
+		// A single "." exchange is a null MX: the domain accepts no mail
+		return !(len(records) == 1 && strings.TrimSuffix(records[0].Host, ".") == ""), nil
+	}
+	if err != nil && !notFound(err) {
+		return false, err
+	}
+
+	hosts, err := c.resolver.LookupHost(ctx, domain)
+	if err != nil {
+		if notFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return len(hosts) > 0, nil
+}
+
+func notFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// SuggestDomain returns the well-known mail domain domain is probably a misspelling
+// of, or "" if there is none. Up to one edit is allowed for short domains and two for
+// longer ones; swapping two adjacent letters counts as one.
+func SuggestDomain(domain string) string {
+	for _, common := range commonMailDomains {
+		if domain == common {
+			return ""
+		}
+	}
+
+	best, bestDistance := "", 0
+	for _, common := range commonMailDomains {
+		limit := 1
+		if len(common) > 8 {
+			limit = 2
+		}
+		distance := editDistance(domain, common)
+		if distance <= limit && (best == "" || distance < bestDistance) {
+			best, bestDistance = common, distance
+		}
+	}
+	return best
+}
+
+// editDistance is the optimal string alignment distance between a and b.
+func editDistance(a, b string) int {
+	rows := make([][]int, len(a)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(b)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(a)][len(b)]
+}
@@ -0,0 +1,75 @@
+package sharedkernel
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowResolver answers after delay, knowing only the domains in mx, and counts lookups.
+type slowResolver struct {
+	delay time.Duration
+	mx    map[string]bool
+
+	mu      sync.Mutex
+	lookups map[string]int
+}
+
+func (r *slowResolver) LookupMX(_ context.Context, name string) ([]*net.MX, error) {
+	time.Sleep(r.delay)
+	r.mu.Lock()
+	r.lookups[name]++
+	r.mu.Unlock()
+	if r.mx[name] {
+		return []*net.MX{{Host: "mx." + name + ".", Pref: 10}}, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r *slowResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+// TestDomainCheckerCheckAll checks addresses at several domains and verifies that each
+// domain is looked up once and that the lookups overlap.
+func TestDomainCheckerCheckAll(t *testing.T) {
+	const delay = 100 * time.Millisecond
+	resolver := &slowResolver{
+		delay:   delay,
+		mx:      map[string]bool{"one.example": true, "two.example": true, "three.example": true},
+		lookups: map[string]int{},
+	}
+	checker := NewDomainChecker(resolver, time.Second, time.Hour, false, 4)
+
+	var addresses []Address
+	for _, raw := range []string{"a@one.example", "b@two.example", "c@three.example", "d@one.example", "e@missing.example"} {
+		address, err := ParseAddress(raw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		addresses = append(addresses, address)
+	}
+
+	start := time.Now()
+	errs := checker.CheckAll(addresses)
+	if elapsed := time.Since(start); elapsed > 3*delay {
+		t.Errorf("4 domains took %v with a %v lookup", elapsed, delay)
+	}
+
+	for i, err := range errs[:4] {
+		if err != nil {
+			t.Errorf("expected %s to pass, got %v", addresses[i].Email, err)
+		}
+	}
+	if !errors.Is(errs[4], ErrUndeliverableDomain) {
+		t.Errorf("expected %s to be undeliverable, got %v", addresses[4].Email, errs[4])
+	}
+	for domain, count := range resolver.lookups {
+		if count != 1 {
+			t.Errorf("expected one lookup of %s, got %d", domain, count)
+		}
+	}
+}
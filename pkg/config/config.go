@@ -22,14 +22,155 @@ type DatabaseConfig struct {
 	ConnMaxLifetime time.Duration `mapstructure:"conn_max_lifetime"` // Corresponds to the 'conn_max_lifetime' key in YAML (e.g. "5m")
 }
 
-// SMTPConfig holds SMTP server details
-type SMTPConfig struct {
+// SMTPServerConfig holds the connection details of one SMTP server
+type SMTPServerConfig struct {
 	Host   string `mapstructure:"host"`
 	Port   int    `mapstructure:"port"`
 	User   string `mapstructure:"user"`
 	Pass   string `mapstructure:"pass"`
-	From   string `mapstructure:"from"`
-	Secure bool   `mapstructure:"secure"`
+	Secure bool   `mapstructure:"secure"` // Used only when tls_mode is empty: implicit TLS on 465, required STARTTLS otherwise
+
+	TLSMode       string `mapstructure:"tls_mode"`        // plain, starttls, opportunistic or tls (implicit)
+	TLSCAFile     string `mapstructure:"tls_ca_file"`     // Custom CA bundle (PEM) used to verify the server
+	TLSServerName string `mapstructure:"tls_server_name"` // Name checked against the server certificate
+	TLSMinVersion string `mapstructure:"tls_min_version"` // Minimum TLS version, e.g. "1.2"
+}
+
+// RelayConfig describes one of several SMTP relays mail can be routed through
+type RelayConfig struct {
+	Name     string `mapstructure:"name"`     // Used in logs and metrics
+	Priority int    `mapstructure:"priority"` // Lower values are tried first
+	Weight   int    `mapstructure:"weight"`   // Traffic share among relays with the same priority
+
+	SMTPServerConfig `mapstructure:",squash"`
+}
+
+// SMTPConfig holds SMTP server details
+type SMTPConfig struct {
+	SMTPServerConfig `mapstructure:",squash"`
+
+	From     string `mapstructure:"from"`
+	FromName string `mapstructure:"from_name"` // Display name shown with the From address
+
+	MaxAttempts     int           `mapstructure:"max_attempts"`      // Delivery attempts before a message is dead-lettered
+	RetryBackoff    time.Duration `mapstructure:"retry_backoff"`     // Delay before the first retry, doubled on every further attempt
+	RetryMaxBackoff time.Duration `mapstructure:"retry_max_backoff"` // Upper bound for the retry delay
+
+	Timeout time.Duration `mapstructure:"timeout"` // Limit for connecting and for each command and data transfer
+
+	PoolSize        int           `mapstructure:"pool_size"`         // Idle connections kept open per server; 0 opens one per message
+	MaxMessagesConn int           `mapstructure:"max_messages_conn"` // Messages sent over one connection before it is replaced
+	PoolIdleTimeout time.Duration `mapstructure:"pool_idle_timeout"` // Idle connections older than this are closed instead of reused
+
+	Relays                []RelayConfig `mapstructure:"relays"`                  // When set, replaces the single server above
+	RelayFailureThreshold int           `mapstructure:"relay_failure_threshold"` // Consecutive failures before a relay is marked down
+	RelayProbeInterval    time.Duration `mapstructure:"relay_probe_interval"`    // How long a down relay is left alone before it is probed
+}
+
+// TransportConfig selects how outgoing mail is delivered
+type TransportConfig struct {
+	Driver string `mapstructure:"driver"` // smtp, sendmail, file, memory or http
+
+	SendmailPath    string        `mapstructure:"sendmail_path"`    // Path of the sendmail binary
+	SendmailArgs    []string      `mapstructure:"sendmail_args"`    // Extra arguments passed before -i -f <from> -- <recipients>
+	SendmailTimeout time.Duration `mapstructure:"sendmail_timeout"` // A sendmail run taking longer is killed and retried
+
+	FileDir string `mapstructure:"file_dir"` // Directory .eml files are written to by the file driver
+
+	HTTPEndpoint string        `mapstructure:"http_endpoint"` // URL the http driver POSTs JSON messages to
+	HTTPToken    string        `mapstructure:"http_token"`    // Optional bearer token for the http driver
+	HTTPTimeout  time.Duration `mapstructure:"http_timeout"`  // Request timeout for the http driver
+
+	MaxMessageSize int64 `mapstructure:"max_message_size"` // Previews warn about messages larger than this many bytes
+}
+
+// DKIMConfig holds DKIM signing settings; signing is enabled when all fields are set
+type DKIMConfig struct {
+	Domain         string `mapstructure:"domain"`           // Signing domain (d= tag)
+	Selector       string `mapstructure:"selector"`         // DNS selector (s= tag), published at <selector>._domainkey.<domain>
+	PrivateKeyPath string `mapstructure:"private_key_path"` // PEM encoded RSA (PKCS#1/PKCS#8) or Ed25519 (PKCS#8) key
+}
+
+// Enabled reports whether DKIM signing is configured
+func (c DKIMConfig) Enabled() bool {
+	return c.Domain != "" && c.Selector != "" && c.PrivateKeyPath != ""
+}
+
+// RateLimitConfig is a send rate; a zero rate means unlimited
+type RateLimitConfig struct {
+	Rate  float64 `mapstructure:"rate"`  // Messages per second
+	Burst int     `mapstructure:"burst"` // Messages that may be sent at once; defaults to one second worth
+}
+
+// DomainRateLimitConfig is the send rate towards one recipient domain
+type DomainRateLimitConfig struct {
+	Domain          string `mapstructure:"domain"` // e.g. gmail.com
+	RateLimitConfig `mapstructure:",squash"`
+}
+
+// RateLimitsConfig holds the outgoing send rate limits
+type RateLimitsConfig struct {
+	Global  RateLimitConfig         `mapstructure:"global"`
+	Domains []DomainRateLimitConfig `mapstructure:"domains"`
+	MaxWait time.Duration           `mapstructure:"max_wait"` // Longer waits put the message back into the queue instead of holding a worker
+}
+
+// BounceConfig holds bounce (delivery status notification) processing settings
+type BounceConfig struct {
+	Maildir               string        `mapstructure:"maildir"`                // Maildir bounces are delivered to; empty disables polling
+	PollInterval          time.Duration `mapstructure:"poll_interval"`          // How often the Maildir is checked for new messages
+	UnsubscribeNewsletter bool          `mapstructure:"unsubscribe_newsletter"` // Remove hard-bounced addresses from the newsletter
+	Secret                string        `mapstructure:"secret"`                 // Key signing notifications posted to /bounces; empty refuses all of them
+}
+
+// MailQueueConfig holds the outbound mail queue (outbox) worker settings
+type MailQueueConfig struct {
+	Workers      int           `mapstructure:"workers"`       // Number of concurrent delivery workers
+	BatchSize    int           `mapstructure:"batch_size"`    // Messages claimed from the outbox per poll
+	PollInterval time.Duration `mapstructure:"poll_interval"` // How often the outbox is polled (e.g. "2s")
+	LockTimeout  time.Duration `mapstructure:"lock_timeout"`  // After this, a message stuck in processing is claimed again
+
+	BulkConcurrency int `mapstructure:"bulk_concurrency"` // Deliveries in flight per bulk send request
+}
+
+// AttachmentConfig limits attachment sizes accepted by the send endpoints
+type AttachmentConfig struct {
+	MaxSize      int64 `mapstructure:"max_size"`       // Maximum size of a single attachment in bytes
+	MaxTotalSize int64 `mapstructure:"max_total_size"` // Maximum size of all attachments of one email in bytes
+}
+
+// AddressConfig holds email address validation settings
+type AddressConfig struct {
+	DisposableDomainsFile string `mapstructure:"disposable_domains_file"` // File listing disposable domains, one per line; empty disables the check
+
+	CheckDomains bool          `mapstructure:"check_domains"` // Refuse addresses whose domain has no MX or A/AAAA records
+	DNSTimeout   time.Duration `mapstructure:"dns_timeout"`   // Lookups taking longer accept the address
+	DNSCacheTTL  time.Duration `mapstructure:"dns_cache_ttl"` // How long a domain check result is reused
+	RejectTypos  bool          `mapstructure:"reject_typos"`  // Also refuse likely misspellings of well-known domains, e.g. gmial.com
+	DNSWorkers   int           `mapstructure:"dns_workers"`   // Concurrent lookups when a bulk request is validated
+}
+
+// TemplateConfig holds email template rendering settings
+type TemplateConfig struct {
+	FallbackLang string `mapstructure:"fallback_lang"` // Language used when a translation is missing for the requested one
+}
+
+// TrackingConfig holds open and click tracking settings; tracking needs base_url and secret
+type TrackingConfig struct {
+	BaseURL string `mapstructure:"base_url"` // Public URL of this service, used in pixel and link URLs
+	Secret  string `mapstructure:"secret"`   // Key signing the tracking tokens
+	Default bool   `mapstructure:"default"`  // Track HTML mails that do not set "track" themselves
+}
+
+// Enabled reports whether tracking is configured
+func (c TrackingConfig) Enabled() bool {
+	return c.BaseURL != "" && c.Secret != ""
+}
+
+// IdempotencyConfig holds the Idempotency-Key settings of the send endpoints
+type IdempotencyConfig struct {
+	TTL         time.Duration `mapstructure:"ttl"`          // How long a response is replayed for a repeated key
+	LockTimeout time.Duration `mapstructure:"lock_timeout"` // How long a request's key stays locked without renewal before a retry may take it over
 }
 
 // ServerConfig holds server configuration
@@ -40,9 +181,19 @@ type ServerConfig struct {
 
 // Config holds the general application configuration
 type Config struct {
-	SMTP     SMTPConfig     `mapstructure:"smtp"`
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"` // <-- NEWLY ADDED FIELD
+	SMTP        SMTPConfig        `mapstructure:"smtp"`
+	Server      ServerConfig      `mapstructure:"server"`
+	Database    DatabaseConfig    `mapstructure:"database"` // <-- NEWLY ADDED FIELD
+	MailQueue   MailQueueConfig   `mapstructure:"mail_queue"`
+	Templates   TemplateConfig    `mapstructure:"templates"`
+	Attachments AttachmentConfig  `mapstructure:"attachments"`
+	DKIM        DKIMConfig        `mapstructure:"dkim"`
+	Transport   TransportConfig   `mapstructure:"transport"`
+	Bounces     BounceConfig      `mapstructure:"bounces"`
+	Idempotency IdempotencyConfig `mapstructure:"idempotency"`
+	RateLimits  RateLimitsConfig  `mapstructure:"rate_limits"`
+	Tracking    TrackingConfig    `mapstructure:"tracking"`
+	Addresses   AddressConfig     `mapstructure:"addresses"`
 }
 
 // GlobalConfig is the global configuration variable
@@ -68,6 +219,8 @@ func LoadConfig() (*Config, error) {
 	fmt.Printf("Using config file: %s\n", configPath) // Log which config file is being used
 	viper.SetConfigFile(configPath)                   // Use the found config file
 
+	setDefaults()
+
 	// Read the config file
 	if err := viper.ReadInConfig(); err != nil {
 		// Specifically check for file not found error
@@ -91,6 +244,43 @@ func LoadConfig() (*Config, error) {
 	return &GlobalConfig, nil
 }
 
+// setDefaults registers fallback values for optional settings
+func setDefaults() {
+	viper.SetDefault("smtp.max_attempts", 5)
+	viper.SetDefault("smtp.retry_backoff", "30s")
+	viper.SetDefault("smtp.retry_max_backoff", "1h")
+	viper.SetDefault("smtp.timeout", "1m")
+	viper.SetDefault("smtp.pool_size", 4)
+	viper.SetDefault("smtp.max_messages_conn", 100)
+	viper.SetDefault("smtp.pool_idle_timeout", "30s")
+	viper.SetDefault("smtp.relay_failure_threshold", 3)
+	viper.SetDefault("smtp.relay_probe_interval", "30s")
+
+	viper.SetDefault("mail_queue.workers", 4)
+	viper.SetDefault("mail_queue.batch_size", 20)
+	viper.SetDefault("mail_queue.poll_interval", "2s")
+	viper.SetDefault("mail_queue.lock_timeout", "5m")
+	viper.SetDefault("mail_queue.bulk_concurrency", 10)
+	viper.SetDefault("templates.fallback_lang", "en")
+	viper.SetDefault("attachments.max_size", 5<<20)
+	viper.SetDefault("attachments.max_total_size", 10<<20)
+	viper.SetDefault("transport.driver", "smtp")
+	viper.SetDefault("transport.sendmail_path", "/usr/sbin/sendmail")
+	viper.SetDefault("transport.sendmail_timeout", "1m")
+	viper.SetDefault("transport.file_dir", "mail")
+	viper.SetDefault("transport.http_timeout", "30s")
+	viper.SetDefault("transport.max_message_size", 25<<20)
+	viper.SetDefault("bounces.poll_interval", "1m")
+	viper.SetDefault("bounces.unsubscribe_newsletter", true)
+	viper.SetDefault("idempotency.ttl", "24h")
+	viper.SetDefault("idempotency.lock_timeout", "1m")
+	viper.SetDefault("rate_limits.max_wait", "1s")
+	viper.SetDefault("tracking.default", true)
+	viper.SetDefault("addresses.dns_timeout", "3s")
+	viper.SetDefault("addresses.dns_cache_ttl", "1h")
+	viper.SetDefault("addresses.dns_workers", 16)
+}
+
 // GetConfig returns the loaded global configuration
 func GetConfig() *Config {
 	return &GlobalConfig
@@ -33,6 +33,38 @@ var (
 			Help: "Current number of HTTP requests being served",
 		},
 	)
+
+	mailRelayDeliveriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mail_relay_deliveries_total",
+			Help: "Total number of delivery attempts per mail relay",
+		},
+		[]string{"relay", "result"},
+	)
+
+	mailRelayUp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mail_relay_up",
+			Help: "Whether a mail relay is considered healthy (1) or down (0)",
+		},
+		[]string{"relay"},
+	)
+
+	mailRateLimitedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mail_rate_limited_total",
+			Help: "Total number of messages held back by a send rate limit, by limiter and whether they waited or were deferred",
+		},
+		[]string{"limiter", "action"},
+	)
+
+	mailRateLimitTokens = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mail_rate_limit_tokens",
+			Help: "Sends currently available under a rate limit; negative values are sends already waiting",
+		},
+		[]string{"limiter"},
+	)
 )
 
 func init() {
@@ -41,6 +73,44 @@ func init() {
 	prometheus.MustRegister(httpRequestsInFlight)
 }
 
+// RegisterMailMetrics registers the mail delivery collectors on registerer, which
+// should be the registry the /metrics endpoint serves
+func RegisterMailMetrics(registerer prometheus.Registerer) {
+	registerer.MustRegister(mailRelayDeliveriesTotal)
+	registerer.MustRegister(mailRelayUp)
+	registerer.MustRegister(mailRateLimitedTotal)
+	registerer.MustRegister(mailRateLimitTokens)
+}
+
+// RecordRelayDelivery counts a delivery attempt through a mail relay
+func RecordRelayDelivery(relay string, success bool) {
+	result := "failure"
+	if success {
+		result = "success"
+	}
+	mailRelayDeliveriesTotal.WithLabelValues(relay, result).Inc()
+}
+
+// SetRelayUp records the health state of a mail relay
+func SetRelayUp(relay string, up bool) {
+	value := 0.0
+	if up {
+		value = 1
+	}
+	mailRelayUp.WithLabelValues(relay).Set(value)
+}
+
+// RecordRateLimited counts a message delayed by a send rate limit. action is "wait"
+// when it was held briefly or "defer" when it was put back into the queue.
+func RecordRateLimited(limiter, action string) {
+	mailRateLimitedTotal.WithLabelValues(limiter, action).Inc()
+}
+
+// SetRateLimitTokens records the sends available under a rate limit
+func SetRateLimitTokens(limiter string, tokens float64) {
+	mailRateLimitTokens.WithLabelValues(limiter).Set(tokens)
+}
+
 // MetricsMiddleware collects HTTP metrics
 func MetricsMiddleware(c *fiber.Ctx) error {
 	start := time.Now()
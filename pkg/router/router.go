@@ -8,19 +8,57 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// Handlers holds the HTTP handlers of all modules
+type Handlers struct {
+	Health      *mailerhandlers.HealthCheckHandler
+	Mailer      *mailerhandlers.MailerHandler
+	Template    *mailerhandlers.TemplateHandler
+	Suppression *mailerhandlers.SuppressionHandler
+	Bounce      *mailerhandlers.BounceHandler
+	Idempotency *mailerhandlers.IdempotencyHandler
+	Tracking    *mailerhandlers.TrackingHandler
+	Preview     *mailerhandlers.PreviewHandler
+	Newsletter  *newsletterhandlers.NewsletterHandler
+	Resource    *resourcehandlers.ResourceHandler
+}
+
 // SetupRoutes registers all routes
-func SetupRoutes(app *fiber.App, healthHandler *mailerhandlers.HealthCheckHandler, mailerHandler *mailerhandlers.MailerHandler, newsletterHandler *newsletterhandlers.NewsletterHandler, resourceHandler *resourcehandlers.ResourceHandler) {
-	app.Get("/health", healthHandler.Handle)
-	app.Post("/send-email", mailerHandler.SendMail)
-	app.Post("/send-bulk-email", mailerHandler.SendBulkEmails)
-	app.Post("/newsletter/subscribe", newsletterHandler.Subscribe)
-	app.Post("/newsletter/unsubscribe", newsletterHandler.Unsubscribe)
-	app.Get("/newsletter/subscribers", newsletterHandler.GetAllActiveSubscribers)
-	app.Post("/resource", resourceHandler.CreateResource)
-	app.Put("/resource/:id", resourceHandler.UpdateResource)
-	app.Delete("/resource/:id", resourceHandler.DeleteResource)
-	app.Get("/resource/:id", resourceHandler.GetResourceByID)
-	app.Get("/resource", resourceHandler.GetResourceByKeyAndLang)
-	app.Get("/resource/lang/:lang_code", resourceHandler.GetAllResourcesByLang)
-	app.Get("/resources", resourceHandler.GetAllResources)
+func SetupRoutes(app *fiber.App, h Handlers) {
+	app.Get("/health", h.Health.Handle)
+	app.Post("/send-email", h.Idempotency.Handle, h.Mailer.SendMail)
+	app.Post("/send-bulk-email", h.Idempotency.Handle, h.Mailer.SendBulkEmails)
+	app.Post("/preview-email", h.Preview.PreviewMail)
+	app.Get("/emails/dead-letters", h.Mailer.GetDeadLetters)
+	app.Get("/emails/log", h.Mailer.SearchDeliveries)
+	app.Get("/emails/scheduled", h.Mailer.GetScheduledMessages)
+	app.Get("/emails/tracking", h.Tracking.GetStats)
+	app.Get("/emails/:id", h.Mailer.GetMessage)
+	app.Post("/emails/:id/requeue", h.Mailer.RequeueMessage)
+	app.Post("/emails/:id/reschedule", h.Mailer.RescheduleMessage)
+	app.Post("/emails/:id/cancel", h.Mailer.CancelMessage)
+	app.Post("/send-template", h.Template.SendTemplate)
+	app.Post("/preview-template", h.Preview.PreviewTemplate)
+	app.Post("/templates", h.Template.CreateTemplate)
+	app.Get("/templates", h.Template.GetAllTemplates)
+	app.Get("/templates/:id", h.Template.GetTemplateByID)
+	app.Put("/templates/:id", h.Template.UpdateTemplate)
+	app.Delete("/templates/:id", h.Template.DeleteTemplate)
+	app.Post("/suppressions", h.Suppression.CreateSuppression)
+	app.Get("/suppressions", h.Suppression.GetAllSuppressions)
+	app.Get("/suppressions/:id", h.Suppression.GetSuppressionByID)
+	app.Put("/suppressions/:id", h.Suppression.UpdateSuppression)
+	app.Delete("/suppressions/:id", h.Suppression.DeleteSuppression)
+	app.Post("/bounces", h.Bounce.ProcessBounce)
+	app.Get("/t/o/:token", h.Tracking.TrackOpen)
+	app.Get("/t/c/:token", h.Tracking.TrackClick)
+	app.Post("/newsletter/subscribe", h.Newsletter.Subscribe)
+	app.Post("/newsletter/unsubscribe", h.Newsletter.Unsubscribe)
+	app.Get("/newsletter/subscribers", h.Newsletter.GetAllActiveSubscribers)
+	app.Post("/resource", h.Resource.CreateResource)
+	app.Put("/resource/:id", h.Resource.UpdateResource)
+	app.Delete("/resource/:id", h.Resource.DeleteResource)
+	app.Get("/resource/:id", h.Resource.GetResourceByID)
+	app.Get("/resource", h.Resource.GetResourceByKeyAndLang)
+	app.Get("/resource/lang/:lang_code", h.Resource.GetAllResourcesByLang)
+	app.Get("/resources", h.Resource.GetAllResources)
 }